@@ -1,3 +1,305 @@
 package alloy
 
-func CheckAlloySetup(messages *map[string][]string, language string) {}
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+func init() {
+	utils.RegisterCheck(utils.NewCheck("alloy", "alloy", nil, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckAlloySetup(report, env, commands.Language, commands.AlloyConfig())
+	}))
+}
+
+const alloyDocURL = "https://grafana.com/docs/alloy/latest/collect/opentelemetry-to-lgtm-stack/"
+
+// Signals of the outputs of the otelcol components.
+var signals = []string{"metrics", "logs", "traces"}
+
+var grafanaOtlpEndpoint = regexp.MustCompile(`^https://.+\.grafana\.net/otlp/?$`)
+
+var instanceID = regexp.MustCompile(`^[0-9]+$`)
+
+// CheckAlloySetup checks the Alloy configuration at configPath, which is a file or a directory of .alloy files.
+func CheckAlloySetup(report *utils.Report, env utils.Env, language string, configPath string) {
+	c, err := LoadConfig(env, configPath)
+	if err != nil {
+		f := utils.Finding{
+			Severity:  utils.ERRORS,
+			Component: "Alloy",
+			Location:  &utils.Location{File: configPath},
+			Message:   fmt.Sprintf("Could not load the Alloy configuration %s: %s", configPath, err),
+		}
+		var fileErr *FileError
+		if errors.As(err, &fileErr) {
+			f.Location = &utils.Location{File: fileErr.File, Line: fileErr.Line}
+			f.Message = fmt.Sprintf("Could not load the Alloy configuration %s: %s", fileErr.File, fileErr.Err)
+		}
+		report.Add(f)
+		return
+	}
+
+	checkGraph(report, c)
+	checkOtlpWiring(report, c)
+	for _, name := range []string{"otelcol.exporter.otlphttp", "otelcol.exporter.otlp"} {
+		for _, e := range c.ComponentsNamed(name) {
+			checkExporter(report, env, c, e)
+		}
+	}
+}
+
+// checkOtlpWiring checks that the OTLP receivers send data to an OTLP exporter, directly or through other components.
+func checkOtlpWiring(report *utils.Report, c *Config) {
+	receivers := c.ComponentsNamed("otelcol.receiver.otlp")
+	if len(receivers) == 0 {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     "otelcol.receiver.otlp",
+			Message:     "No otelcol.receiver.otlp component is defined, so applications can't send OTLP data to Alloy",
+			Remediation: `Add an otelcol.receiver.otlp "default" component with grpc and http blocks`,
+			DocURL:      alloyDocURL,
+		})
+	}
+	if len(c.ComponentsNamed("otelcol.exporter.otlphttp")) == 0 && len(c.ComponentsNamed("otelcol.exporter.otlp")) == 0 {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     "otelcol.exporter.otlphttp",
+			Message:     "No otelcol.exporter.otlphttp component is defined, so no data is sent to Grafana Cloud",
+			Remediation: `Add an otelcol.exporter.otlphttp "grafana_cloud" component with the OTLP endpoint of your Grafana Cloud stack`,
+			DocURL:      alloyDocURL,
+		})
+	}
+
+	for _, r := range receivers {
+		var exporters []string
+		for _, comp := range c.Downstream(r) {
+			if comp.Name() == "otelcol.exporter.otlphttp" || comp.Name() == "otelcol.exporter.otlp" {
+				exporters = append(exporters, comp.ID())
+			}
+		}
+		if len(exporters) == 0 {
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "Alloy",
+				Subject:     r.Path("output"),
+				Location:    r.LocationOf(r.Block.Line),
+				Message:     fmt.Sprintf("Component %s does not send data to any otelcol.exporter.otlphttp or otelcol.exporter.otlp component", r.ID()),
+				Remediation: fmt.Sprintf("Set the metrics, logs and traces of the output block of %s to the input of an exporter or processor, e.g. [otelcol.processor.batch.default.input]", r.ID()),
+				DocURL:      alloyDocURL,
+			})
+			continue
+		}
+		report.Add(utils.Finding{
+			Severity:  utils.CHECKS,
+			Component: "Alloy",
+			Subject:   r.Path("output"),
+			Location:  r.LocationOf(r.Block.Line),
+			Message:   fmt.Sprintf("Component %s sends data to %s", r.ID(), strings.Join(exporters, ", ")),
+		})
+	}
+}
+
+// Outputs returns the references of the output block of a component, by signal.
+func (c *Config) Outputs(comp *Component) map[string][]Reference {
+	outputs := map[string][]Reference{}
+	output := comp.Block.Body.Block("output")
+	if output == nil {
+		return outputs
+	}
+	for _, signal := range signals {
+		if a := output.Body.Attribute(signal); a != nil {
+			outputs[signal] = References(a.Value)
+		}
+	}
+	return outputs
+}
+
+// Downstream returns the components that receive data from comp, directly or
+// through other components, in the order they are found.
+func (c *Config) Downstream(comp *Component) []*Component {
+	var found []*Component
+	seen := map[*Component]bool{comp: true}
+	queue := []*Component{comp}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		outputs := c.Outputs(current)
+		for _, signal := range signals {
+			for _, ref := range outputs[signal] {
+				next, _ := c.Resolve(ref.Path)
+				if next == nil || seen[next] {
+					continue
+				}
+				seen[next] = true
+				found = append(found, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	return found
+}
+
+func checkExporter(report *utils.Report, env utils.Env, c *Config, e *Component) {
+	client := e.Block.Body.Block("client")
+	if client == nil {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     e.Path("client"),
+			Location:    e.LocationOf(e.Block.Line),
+			Message:     fmt.Sprintf("Component %s has no client block, so it has no endpoint to send data to", e.ID()),
+			Remediation: "Add a client block with the OTLP endpoint of your Grafana Cloud stack",
+			DocURL:      alloyDocURL,
+		})
+		return
+	}
+
+	endpoint := client.Body.Attribute("endpoint")
+	if endpoint == nil {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     e.Path("client", "endpoint"),
+			Location:    e.LocationOf(client.Line),
+			Message:     fmt.Sprintf("Component %s has no endpoint", e.ID()),
+			Remediation: "Set the endpoint of the client block to the OTLP endpoint of your Grafana Cloud stack",
+			DocURL:      alloyDocURL,
+		})
+		return
+	}
+	value, ok := StringValue(env, endpoint.Value)
+	if !ok {
+		return
+	}
+	grafana := false
+	switch {
+	case e.Name() == "otelcol.exporter.otlp" && strings.Contains(value, "grafana.net"):
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     e.Path("client", "endpoint"),
+			Location:    e.LocationOf(endpoint.Line),
+			Message:     fmt.Sprintf("Component %s sends data to Grafana Cloud with gRPC, but the Grafana Cloud OTLP endpoint only supports HTTP", e.ID()),
+			Remediation: "Use an otelcol.exporter.otlphttp component instead",
+			DocURL:      alloyDocURL,
+		})
+		return
+	case grafanaOtlpEndpoint.MatchString(value):
+		grafana = true
+		report.Add(utils.Finding{
+			Severity:  utils.CHECKS,
+			Component: "Alloy",
+			Subject:   e.Path("client", "endpoint"),
+			Location:  e.LocationOf(endpoint.Line),
+			Message:   fmt.Sprintf("Endpoint of %s set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp", e.ID()),
+		})
+	case strings.Contains(value, "localhost"):
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "Alloy",
+			Subject:     e.Path("client", "endpoint"),
+			Location:    e.LocationOf(endpoint.Line),
+			Message:     fmt.Sprintf("Endpoint of %s is set to localhost. Update to a Grafana endpoint similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp to be able to send telemetry to your Grafana Cloud instance", e.ID()),
+			Remediation: "Copy the OTLP endpoint from the OpenTelemetry section of your Grafana Cloud stack",
+			DocURL:      alloyDocURL,
+		})
+	default:
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     e.Path("client", "endpoint"),
+			Location:    e.LocationOf(endpoint.Line),
+			Message:     fmt.Sprintf("Endpoint of %s is not set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp", e.ID()),
+			Remediation: "Copy the OTLP endpoint from the OpenTelemetry section of your Grafana Cloud stack",
+			DocURL:      alloyDocURL,
+		})
+	}
+
+	auth := client.Body.Attribute("auth")
+	if auth == nil {
+		if grafana {
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "Alloy",
+				Subject:     e.Path("client", "auth"),
+				Location:    e.LocationOf(client.Line),
+				Message:     fmt.Sprintf("Component %s sends data to Grafana Cloud without credentials", e.ID()),
+				Remediation: `Set auth to the handler of an otelcol.auth.basic component, e.g. otelcol.auth.basic.grafana_cloud.handler`,
+				DocURL:      alloyDocURL,
+			})
+		}
+		return
+	}
+	checkAuth(report, env, c, e, auth)
+}
+
+func checkAuth(report *utils.Report, env utils.Env, c *Config, e *Component, auth *Attribute) {
+	path := e.Path("client", "auth")
+	a, _ := c.Resolve(Path(auth.Value))
+	switch {
+	case a == nil:
+		// Reported by checkGraph.
+		return
+	case a.Name() != "otelcol.auth.basic":
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "Alloy",
+			Subject:     path,
+			Location:    e.LocationOf(auth.Line),
+			Message:     fmt.Sprintf("Component %s uses %s as auth. Grafana Cloud expects otelcol.auth.basic", e.ID(), a.ID()),
+			Remediation: "Use an otelcol.auth.basic component with the instance ID and token of your Grafana Cloud stack",
+			DocURL:      alloyDocURL,
+		})
+		return
+	}
+
+	username := a.Block.Body.Attribute("username")
+	if username == nil {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     a.Path("username"),
+			Location:    a.LocationOf(a.Block.Line),
+			Message:     fmt.Sprintf("Component %s has no username", a.ID()),
+			Remediation: "Set username to the instance ID of your Grafana Cloud stack",
+			DocURL:      alloyDocURL,
+		})
+	} else if value, ok := StringValue(env, username.Value); ok {
+		if instanceID.MatchString(value) {
+			report.Add(utils.Finding{
+				Severity:  utils.CHECKS,
+				Component: "Alloy",
+				Subject:   a.Path("username"),
+				Location:  a.LocationOf(username.Line),
+				Message:   fmt.Sprintf("Username of %s is a Grafana Cloud instance ID", a.ID()),
+			})
+		} else {
+			report.Add(utils.Finding{
+				Severity:    utils.WARNINGS,
+				Component:   "Alloy",
+				Subject:     a.Path("username"),
+				Location:    a.LocationOf(username.Line),
+				Message:     fmt.Sprintf("Username of %s is %q, which does not look like a Grafana Cloud instance ID", a.ID(), value),
+				Remediation: "Set username to the instance ID of your Grafana Cloud stack, which is a number",
+				DocURL:      alloyDocURL,
+			})
+		}
+	}
+	if a.Block.Body.Attribute("password") == nil {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Alloy",
+			Subject:     a.Path("password"),
+			Location:    a.LocationOf(a.Block.Line),
+			Message:     fmt.Sprintf("Component %s has no password", a.ID()),
+			Remediation: "Set password to a Grafana Cloud access policy token, e.g. sys.env(\"GRAFANA_CLOUD_TOKEN\")",
+			DocURL:      alloyDocURL,
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package alloy
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+const validConfig = `
+otelcol.receiver.otlp "default" {
+  grpc {}
+  http {}
+
+  output {
+    metrics = [otelcol.processor.batch.default.input]
+    logs    = [otelcol.processor.batch.default.input]
+    traces  = [otelcol.processor.batch.default.input]
+  }
+}
+
+otelcol.processor.batch "default" {
+  output {
+    metrics = [otelcol.exporter.otlphttp.grafana_cloud.input]
+    logs    = [otelcol.exporter.otlphttp.grafana_cloud.input]
+    traces  = [otelcol.exporter.otlphttp.grafana_cloud.input]
+  }
+}
+
+otelcol.auth.basic "grafana_cloud" {
+  username = sys.env("GRAFANA_CLOUD_INSTANCE_ID")
+  password = sys.env("GRAFANA_CLOUD_API_KEY")
+}
+
+otelcol.exporter.otlphttp "grafana_cloud" {
+  client {
+    endpoint = "https://otlp-gateway-prod-us-east-0.grafana.net/otlp"
+    auth     = otelcol.auth.basic.grafana_cloud.handler
+  }
+}
+`
+
+func TestCheckAlloySetup(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		configPath   string
+		wantErrors   []string
+		wantWarnings []string
+		wantChecks   []string
+	}{
+		{
+			name:       "valid",
+			files:      fstest.MapFS{"config.alloy": {Data: []byte(validConfig)}},
+			configPath: "config.alloy",
+			wantChecks: []string{
+				"otelcol.receiver.otlp.default.output",
+				"otelcol.exporter.otlphttp.grafana_cloud.client.endpoint",
+				"otelcol.auth.basic.grafana_cloud.username",
+			},
+		},
+		{
+			name: "directory of files",
+			files: fstest.MapFS{
+				"alloy/receiver.alloy": {Data: []byte(`
+otelcol.receiver.otlp "default" {
+  http {}
+  output {
+    traces = [otelcol.exporter.otlphttp.grafana_cloud.input]
+  }
+}`)},
+				"alloy/exporter.alloy": {Data: []byte(`
+otelcol.exporter.otlphttp "grafana_cloud" {
+  client {
+    endpoint = "http://localhost:4318"
+  }
+}`)},
+				"alloy/README.md": {Data: []byte("not a config")},
+			},
+			configPath:   "alloy",
+			wantWarnings: []string{"otelcol.exporter.otlphttp.grafana_cloud.client.endpoint"},
+			wantChecks:   []string{"otelcol.receiver.otlp.default.output"},
+		},
+		{
+			name:       "missing file",
+			files:      fstest.MapFS{},
+			configPath: "config.alloy",
+			wantErrors: []string{""},
+		},
+		{
+			name:       "syntax error",
+			files:      fstest.MapFS{"config.alloy": {Data: []byte("otelcol.receiver.otlp \"default\" {\n")}},
+			configPath: "config.alloy",
+			wantErrors: []string{""},
+		},
+		{
+			name: "no receiver or exporter",
+			files: fstest.MapFS{"config.alloy": {Data: []byte(`
+prometheus.remote_write "default" {
+  endpoint {
+    url = "https://prometheus-prod-13-prod-us-east-0.grafana.net/api/prom/push"
+  }
+}`)}},
+			configPath:   "config.alloy",
+			wantErrors:   []string{"otelcol.receiver.otlp", "otelcol.exporter.otlphttp"},
+			wantWarnings: []string{"prometheus.remote_write.default"},
+		},
+		{
+			name: "receiver not wired and exporter without credentials",
+			files: fstest.MapFS{"config.alloy": {Data: []byte(`
+otelcol.receiver.otlp "default" {
+  grpc {}
+  output {
+    traces = [otelcol.processor.batch.default.input]
+  }
+}
+
+otelcol.exporter.otlphttp "default" {
+  client {
+    endpoint = "https://otlp-gateway-prod-eu-west-2.grafana.net/otlp"
+  }
+}`)}},
+			configPath:   "config.alloy",
+			wantErrors:   []string{"otelcol.receiver.otlp.default", "otelcol.receiver.otlp.default.output", "otelcol.exporter.otlphttp.default.client.auth"},
+			wantWarnings: []string{"otelcol.exporter.otlphttp.default"},
+			wantChecks:   []string{"otelcol.exporter.otlphttp.default.client.endpoint"},
+		},
+		{
+			name: "grpc exporter to grafana cloud",
+			files: fstest.MapFS{"config.alloy": {Data: []byte(`
+otelcol.receiver.otlp "default" {
+  output {
+    traces = [otelcol.exporter.otlp.default.input]
+  }
+}
+
+otelcol.exporter.otlp "default" {
+  client {
+    endpoint = "otlp-gateway-prod-us-east-0.grafana.net:443"
+  }
+}`)}},
+			configPath: "config.alloy",
+			wantErrors: []string{"otelcol.exporter.otlp.default.client.endpoint"},
+			wantChecks: []string{"otelcol.receiver.otlp.default.output"},
+		},
+		{
+			name: "invalid auth",
+			files: fstest.MapFS{"config.alloy": {Data: []byte(`
+otelcol.receiver.otlp "default" {
+  output {
+    traces = [otelcol.exporter.otlphttp.a.input, otelcol.exporter.otlphttp.b.input]
+  }
+}
+
+otelcol.auth.bearer "token" {
+  token = "secret"
+}
+
+otelcol.auth.basic "grafana_cloud" {
+  username = "my-stack"
+}
+
+otelcol.exporter.otlphttp "a" {
+  client {
+    endpoint = "https://otlp-gateway-prod-us-east-0.grafana.net/otlp"
+    auth     = otelcol.auth.bearer.token.handler
+  }
+}
+
+otelcol.exporter.otlphttp "b" {
+  client {
+    endpoint = "https://otlp-gateway-prod-us-east-0.grafana.net/otlp"
+    auth     = otelcol.auth.basic.grafana_cloud.handler
+  }
+}
+
+otelcol.exporter.otlphttp "c" {
+  client {
+    endpoint = "https://otlp-gateway-prod-us-east-0.grafana.net/otlp"
+    auth     = otelcol.auth.basic.missing.handler
+  }
+}`)}},
+			configPath:   "config.alloy",
+			wantErrors:   []string{"otelcol.exporter.otlphttp.c", "otelcol.auth.basic.grafana_cloud.password"},
+			wantWarnings: []string{"otelcol.exporter.otlphttp.c", "otelcol.exporter.otlphttp.a.client.auth", "otelcol.auth.basic.grafana_cloud.username"},
+			wantChecks: []string{
+				"otelcol.receiver.otlp.default.output",
+				"otelcol.exporter.otlphttp.a.client.endpoint",
+				"otelcol.exporter.otlphttp.b.client.endpoint",
+				"otelcol.exporter.otlphttp.c.client.endpoint",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := utils.Env{
+				FS: tt.files,
+				Getenv: func(name string) string {
+					return map[string]string{"GRAFANA_CLOUD_INSTANCE_ID": "123456", "GRAFANA_CLOUD_API_KEY": "glc_token"}[name]
+				},
+			}
+			report := utils.NewReport()
+			CheckAlloySetup(report, env, "java", tt.configPath)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			if got := utilstest.Subjects(report, utils.CHECKS); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestCheckAlloySetupLocations(t *testing.T) {
+	env := utils.Env{FS: fstest.MapFS{"config.alloy": {Data: []byte("logging {\n  level = \"info\"\n\n")}}}
+	report := utils.NewReport()
+	CheckAlloySetup(report, env, "java", "config.alloy")
+
+	errs := report.BySeverity(utils.ERRORS)
+	if len(errs) != 1 || errs[0].Location == nil || *errs[0].Location != (utils.Location{File: "config.alloy", Line: 4}) {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+}
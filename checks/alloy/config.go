@@ -0,0 +1,207 @@
+package alloy
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+// Blocks defined on the top level of a file that are not components.
+var configBlocks = []string{"logging", "tracing", "http", "remotecfg", "livedebugging", "argument", "export", "declare"}
+
+// Component is a block defining a component, such as otelcol.receiver.otlp "default".
+type Component struct {
+	Block *Block
+	File  string
+}
+
+// Name returns the name of the component, such as otelcol.receiver.otlp.
+func (c *Component) Name() string {
+	return c.Block.Name
+}
+
+// ID returns the name and label of the component, such as otelcol.receiver.otlp.default,
+// which is how other components reference it.
+func (c *Component) ID() string {
+	if c.Block.Label == "" {
+		return c.Block.Name
+	}
+	return c.Block.Name + "." + c.Block.Label
+}
+
+// Path returns the path of an attribute or block inside the component, such as otelcol.exporter.otlphttp.default.client.endpoint.
+func (c *Component) Path(path ...string) string {
+	return strings.Join(append([]string{c.ID()}, path...), ".")
+}
+
+// LocationOf returns the location of a line of the file the component is
+// defined in, or nil when c is nil.
+func (c *Component) LocationOf(line int) *utils.Location {
+	if c == nil {
+		return nil
+	}
+	return &utils.Location{File: c.File, Line: line}
+}
+
+// References returns the references used in the attributes of the component,
+// including the ones of its nested blocks, in the order they are defined.
+func (c *Component) References() []Reference {
+	var refs []Reference
+	var walk func(body Body)
+	walk = func(body Body) {
+		for _, stmt := range body {
+			switch stmt := stmt.(type) {
+			case *Attribute:
+				refs = append(refs, References(stmt.Value)...)
+			case *Block:
+				walk(stmt.Body)
+			}
+		}
+	}
+	walk(c.Block.Body)
+	return refs
+}
+
+// Config is the content of the Alloy configuration files.
+type Config struct {
+	Files []string
+	// Components are the components of all files, in the order they are defined.
+	Components []*Component
+}
+
+// FileError is returned by LoadConfig when a file can't be read or is not valid Alloy syntax.
+type FileError struct {
+	File string
+	// Line is the line of the syntax error, or 0 when the file can't be read.
+	Line int
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.File, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// LoadConfig loads the Alloy configuration at configPath, which is either a
+// file or a directory, in which case all of its .alloy files are loaded.
+func LoadConfig(env utils.Env, configPath string) (*Config, error) {
+	files := []string{configPath}
+	if entries, err := env.ReadDir(configPath); err == nil {
+		files = nil
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".alloy") {
+				files = append(files, path.Join(configPath, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, &FileError{File: configPath, Err: fmt.Errorf("no .alloy files found")}
+		}
+	}
+
+	c := &Config{Files: files}
+	for _, file := range files {
+		data, err := env.ReadFile(file)
+		if err != nil {
+			return nil, &FileError{File: file, Err: err}
+		}
+		body, err := Parse(data)
+		if err != nil {
+			fileErr := &FileError{File: file, Err: err}
+			if syntaxErr, ok := err.(*SyntaxError); ok {
+				fileErr.Line, fileErr.Err = syntaxErr.Line, fmt.Errorf("%s", syntaxErr.Message)
+			}
+			return nil, fileErr
+		}
+		c.add(file, body)
+	}
+	return c, nil
+}
+
+// ParseConfig parses the content of a single Alloy configuration file.
+func ParseConfig(file string, data []byte) (*Config, error) {
+	body, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{Files: []string{file}}
+	c.add(file, body)
+	return c, nil
+}
+
+func (c *Config) add(file string, body Body) {
+	for _, stmt := range body {
+		block, ok := stmt.(*Block)
+		if !ok || block.Label == "" || strings.HasPrefix(block.Name, "import.") || slices.Contains(configBlocks, block.Name) {
+			continue
+		}
+		c.Components = append(c.Components, &Component{Block: block, File: file})
+	}
+}
+
+// Component returns the component with the given ID, or nil.
+func (c *Config) Component(id string) *Component {
+	for _, comp := range c.Components {
+		if comp.ID() == id {
+			return comp
+		}
+	}
+	return nil
+}
+
+// ComponentsNamed returns the components with the given name, such as otelcol.receiver.otlp.
+func (c *Config) ComponentsNamed(name string) []*Component {
+	var found []*Component
+	for _, comp := range c.Components {
+		if comp.Name() == name {
+			found = append(found, comp)
+		}
+	}
+	return found
+}
+
+// Resolve returns the component a reference points to, and the name of the
+// value it exports, such as input for otelcol.exporter.otlphttp.default.input.
+// It returns nil when no component is defined with a prefix of the reference.
+func (c *Config) Resolve(ref string) (*Component, string) {
+	for id := ref; id != ""; {
+		if comp := c.Component(id); comp != nil {
+			return comp, strings.TrimPrefix(strings.TrimPrefix(ref, id), ".")
+		}
+		i := strings.LastIndex(id, ".")
+		if i < 0 {
+			break
+		}
+		id = id[:i]
+	}
+	return nil, ""
+}
+
+// StringValue evaluates an expression made of strings, the sys.env and env
+// functions and the + operator. It returns false for any other expression.
+func StringValue(env utils.Env, e Expr) (string, bool) {
+	switch e := e.(type) {
+	case *Literal:
+		if e.Kind == STRING || e.Kind == NUMBER {
+			return e.Value, true
+		}
+	case *Call:
+		if f := Path(e.Func); (f == "sys.env" || f == "env") && len(e.Args) == 1 {
+			if name, ok := StringValue(env, e.Args[0]); ok {
+				return env.Getenv(name), true
+			}
+		}
+	case *Binary:
+		if e.Op == "+" {
+			left, okLeft := StringValue(env, e.Left)
+			right, okRight := StringValue(env, e.Right)
+			return left + right, okLeft && okRight
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,551 @@
+package alloy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/collector"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Status of the conversion of a collector component to Alloy.
+const CONVERTED = "converted"
+const REVIEW = "review"
+const BUILTIN = "builtin"
+const UNSUPPORTED = "unsupported"
+
+// equivalent is the Alloy component replacing a collector component.
+type equivalent struct {
+	// name of the Alloy component, or "" when there is none.
+	name string
+	// convert returns the settings of the Alloy component. When nil, the
+	// settings of the collector component are copied as-is, and need review.
+	convert func(v *converter, comp *collector.Component) Body
+	// builtin is set when Alloy does the same without a component.
+	builtin bool
+	// note explains the differences with the collector component.
+	note string
+}
+
+// Alloy equivalents of the collector components, by kind and type.
+var equivalents = map[string]map[string]equivalent{
+	collector.RECEIVERS: {
+		"otlp":              {name: "otelcol.receiver.otlp", convert: convertOtlpReceiver},
+		"jaeger":            {name: "otelcol.receiver.jaeger"},
+		"zipkin":            {name: "otelcol.receiver.zipkin", convert: copySettings},
+		"kafka":             {name: "otelcol.receiver.kafka"},
+		"opencensus":        {name: "otelcol.receiver.opencensus", convert: copySettings},
+		"filelog":           {name: "otelcol.receiver.filelog"},
+		"syslog":            {name: "otelcol.receiver.syslog"},
+		"tcplog":            {name: "otelcol.receiver.tcplog"},
+		"vcenter":           {name: "otelcol.receiver.vcenter"},
+		"influxdb":          {name: "otelcol.receiver.influxdb", convert: copySettings},
+		"datadog":           {name: "otelcol.receiver.datadog", convert: copySettings},
+		"file_stats":        {name: "otelcol.receiver.file_stats"},
+		"googlecloudpubsub": {name: "otelcol.receiver.googlecloudpubsub"},
+		"solace":            {name: "otelcol.receiver.solace"},
+		"prometheus": {name: "otelcol.receiver.prometheus", convert: noSettings,
+			note: "Alloy scrapes targets with prometheus.scrape components, which forward the metrics to otelcol.receiver.prometheus. Convert config.scrape_configs to prometheus.scrape components"},
+		"hostmetrics": {note: "Use prometheus.exporter.unix, scraped by prometheus.scrape, whose metrics have Prometheus names instead of the OpenTelemetry semantic conventions"},
+	},
+	collector.PROCESSORS: {
+		"batch":                 {name: "otelcol.processor.batch", convert: copySettings},
+		"memory_limiter":        {name: "otelcol.processor.memory_limiter", convert: copySettings},
+		"attributes":            {name: "otelcol.processor.attributes"},
+		"resourcedetection":     {name: "otelcol.processor.resourcedetection"},
+		"k8sattributes":         {name: "otelcol.processor.k8sattributes"},
+		"tail_sampling":         {name: "otelcol.processor.tail_sampling"},
+		"probabilistic_sampler": {name: "otelcol.processor.probabilistic_sampler", convert: copySettings},
+		"span":                  {name: "otelcol.processor.span"},
+		"filter":                {name: "otelcol.processor.filter"},
+		"transform":             {name: "otelcol.processor.transform"},
+		"groupbyattrs":          {name: "otelcol.processor.groupbyattrs", convert: copySettings},
+		"deltatocumulative":     {name: "otelcol.processor.deltatocumulative", convert: copySettings},
+		"interval":              {name: "otelcol.processor.interval", convert: copySettings},
+		"resource":              {note: "Use otelcol.processor.transform with statements on the resource context, or otelcol.processor.attributes"},
+	},
+	collector.EXPORTERS: {
+		"otlp":          {name: "otelcol.exporter.otlp", convert: convertOtlpExporter},
+		"otlphttp":      {name: "otelcol.exporter.otlphttp", convert: convertOtlpExporter},
+		"debug":         {name: "otelcol.exporter.debug", convert: copySettings},
+		"loadbalancing": {name: "otelcol.exporter.loadbalancing"},
+		"kafka":         {name: "otelcol.exporter.kafka"},
+		"awss3":         {name: "otelcol.exporter.awss3"},
+		"googlecloud":   {name: "otelcol.exporter.googlecloud"},
+		"splunkhec":     {name: "otelcol.exporter.splunkhec"},
+		"datadog":       {name: "otelcol.exporter.datadog"},
+		"syslog":        {name: "otelcol.exporter.syslog"},
+		"loki": {name: "otelcol.exporter.loki", convert: noSettings,
+			note: "otelcol.exporter.loki forwards the logs to loki components. Set forward_to to a loki.write component with the endpoint of the exporter"},
+		"prometheus": {name: "otelcol.exporter.prometheus", convert: noSettings,
+			note: "otelcol.exporter.prometheus forwards the metrics to prometheus components instead of serving them. Set forward_to to a prometheus.remote_write component, or serve them with prometheus.exporter.self"},
+		"prometheusremotewrite": {name: "otelcol.exporter.prometheus", convert: noSettings,
+			note: "otelcol.exporter.prometheus forwards the metrics to prometheus components. Set forward_to to a prometheus.remote_write component with the endpoint of the exporter"},
+	},
+	collector.CONNECTORS: {
+		"spanmetrics":  {name: "otelcol.connector.spanmetrics"},
+		"servicegraph": {name: "otelcol.connector.servicegraph"},
+		"count":        {name: "otelcol.connector.count"},
+		"forward":      {builtin: true, note: "Alloy components can send data to several components, so the components sending to forward send to the components it sends to instead"},
+	},
+	collector.EXTENSIONS: {
+		"basicauth":              {name: "otelcol.auth.basic", convert: convertBasicAuth},
+		"bearertokenauth":        {name: "otelcol.auth.bearer", convert: copySettings},
+		"oauth2client":           {name: "otelcol.auth.oauth2"},
+		"headers_setter":         {name: "otelcol.auth.headers"},
+		"sigv4auth":              {name: "otelcol.auth.sigv4"},
+		"jaeger_remote_sampling": {name: "otelcol.extension.jaeger_remote_sampling"},
+		"health_check":           {builtin: true, note: "Alloy serves /-/ready and /-/healthy on its HTTP server"},
+		"pprof":                  {builtin: true, note: "Alloy serves /debug/pprof on its HTTP server"},
+		"zpages":                 {builtin: true, note: "Use the Alloy UI and live debugging to inspect the components and the data going through them"},
+	},
+}
+
+// Kinds of collector components, in the order they are converted.
+var collectorKinds = []string{collector.EXTENSIONS, collector.RECEIVERS, collector.PROCESSORS, collector.CONNECTORS, collector.EXPORTERS}
+
+// ComponentConversion is the result of the conversion of a collector component.
+type ComponentConversion struct {
+	Component *collector.Component
+	Status    string
+	// Alloy are the IDs of the Alloy components replacing the collector
+	// component, such as otelcol.receiver.otlp.default. Processors used on
+	// several pipelines are replaced by a component per pipeline.
+	Alloy []string
+	Note  string
+}
+
+// Conversion is a collector config converted to Alloy.
+type Conversion struct {
+	Components []ComponentConversion
+	Config     Body
+}
+
+// instance is a collector component as it is used on the pipelines. Alloy
+// components send data to the same components wherever they are used, so a
+// processor used on several pipelines has an instance for each pipeline.
+type instance struct {
+	comp     *collector.Component
+	pipeline string
+}
+
+type converter struct {
+	c *collector.Config
+	// instances has the instances of each component used on a pipeline or enabled, in the order they are found.
+	instances map[*collector.Component][]instance
+	// ids has the ID of the Alloy component replacing each instance.
+	ids map[instance]string
+	// outputs has the instances each instance sends data to, by signal.
+	outputs map[instance]map[string][]instance
+	// review is set when a setting of the component being converted can't be converted exactly.
+	review bool
+}
+
+// Convert converts the components of a collector config used on its pipelines
+// and the extensions it enables to Alloy components, wired the same way.
+func Convert(c *collector.Config) *Conversion {
+	v := &converter{
+		c:         c,
+		instances: map[*collector.Component][]instance{},
+		ids:       map[instance]string{},
+		outputs:   map[instance]map[string][]instance{},
+	}
+	v.wire()
+
+	labels := map[string]bool{}
+	var components []*collector.Component
+	for _, kind := range collectorKinds {
+		for _, comp := range c.ComponentsOfKind(kind) {
+			if len(v.instances[comp]) == 0 {
+				continue
+			}
+			components = append(components, comp)
+			e := equivalents[kind][comp.ID.Type]
+			if e.name == "" {
+				continue
+			}
+			for _, inst := range v.instances[comp] {
+				base := alloyLabel(comp.ID)
+				if inst.pipeline != "" {
+					base += "_" + invalidLabel.ReplaceAllString(inst.pipeline, "_")
+				}
+				label := base
+				for i := 2; labels[e.name+"."+label]; i++ {
+					label = fmt.Sprintf("%s_%d", base, i)
+				}
+				labels[e.name+"."+label] = true
+				v.ids[inst] = e.name + "." + label
+			}
+		}
+	}
+
+	conv := &Conversion{}
+	if level := logLevel(c); level != "" {
+		conv.Config = append(conv.Config, &Block{Name: "logging", Body: Body{
+			&Attribute{Name: "level", Value: &Literal{Kind: STRING, Value: level}},
+		}})
+	}
+	var unsupported []string
+	for _, comp := range components {
+		result, stmts := v.convert(comp)
+		conv.Components = append(conv.Components, result)
+		conv.Config = append(conv.Config, stmts...)
+		if result.Status == UNSUPPORTED {
+			unsupported = append(unsupported, comp.Path())
+		}
+	}
+	if len(unsupported) > 0 {
+		conv.Config = append(conv.Config, &Comment{Text: "TODO: not converted, as they have no Alloy equivalent: " + strings.Join(unsupported, ", ")})
+	}
+	return conv
+}
+
+// wire records the instances of the components used on the pipelines and of
+// the enabled extensions, and the instances each instance sends data to.
+func (v *converter) wire() {
+	pipelines := map[string]int{}
+	for _, p := range v.c.Service.Pipelines {
+		for _, id := range p.Processors {
+			pipelines[id]++
+		}
+	}
+
+	for _, id := range v.c.Service.Extensions {
+		if ext, ok := v.c.Extensions[id]; ok {
+			v.add(instance{comp: ext})
+		}
+	}
+	for _, signal := range signals {
+		for _, p := range v.c.PipelinesOfSignal(signal) {
+			var stages [][]instance
+			stages = append(stages, v.lookup(p.Receivers, "", v.c.Receivers, v.c.Connectors))
+			for _, id := range p.Processors {
+				pipeline := ""
+				if pipelines[id] > 1 {
+					pipeline = p.ID.String()
+				}
+				stages = append(stages, v.lookup([]string{id}, pipeline, v.c.Processors))
+			}
+			stages = append(stages, v.lookup(p.Exporters, "", v.c.Exporters, v.c.Connectors))
+
+			for i, stage := range stages {
+				for _, inst := range stage {
+					v.add(inst)
+					if i+1 == len(stages) {
+						continue
+					}
+					if v.outputs[inst] == nil {
+						v.outputs[inst] = map[string][]instance{}
+					}
+					for _, next := range stages[i+1] {
+						if !slices.Contains(v.outputs[inst][signal], next) {
+							v.outputs[inst][signal] = append(v.outputs[inst][signal], next)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (v *converter) add(inst instance) {
+	if !slices.Contains(v.instances[inst.comp], inst) {
+		v.instances[inst.comp] = append(v.instances[inst.comp], inst)
+	}
+}
+
+// lookup returns the instances of the components with the given IDs, from the first map defining them.
+func (v *converter) lookup(ids []string, pipeline string, maps ...map[string]*collector.Component) []instance {
+	var found []instance
+	for _, id := range ids {
+		for _, m := range maps {
+			if comp, ok := m[id]; ok {
+				found = append(found, instance{comp: comp, pipeline: pipeline})
+				break
+			}
+		}
+	}
+	return found
+}
+
+func (v *converter) convert(comp *collector.Component) (ComponentConversion, []Stmt) {
+	e, ok := equivalents[comp.Kind][comp.ID.Type]
+	result := ComponentConversion{Component: comp, Note: e.note}
+	switch {
+	case e.builtin:
+		result.Status = BUILTIN
+		return result, nil
+	case !ok || e.name == "":
+		result.Status = UNSUPPORTED
+		return result, nil
+	}
+
+	var blocks []Stmt
+	v.review = false
+	for _, inst := range v.instances[comp] {
+		var body Body
+		if e.convert != nil {
+			body = e.convert(v, comp)
+		} else {
+			body = v.settings(comp.Node)
+			v.review = v.review || len(body) > 0
+		}
+		if output := v.output(inst); output != nil {
+			body = append(body, output)
+		}
+		result.Alloy = append(result.Alloy, v.ids[inst])
+		blocks = append(blocks, &Block{Name: e.name, Label: strings.TrimPrefix(v.ids[inst], e.name+"."), Body: body})
+	}
+
+	result.Status = CONVERTED
+	var stmts []Stmt
+	switch {
+	case e.note != "":
+		result.Status = REVIEW
+		stmts = append(stmts, &Comment{Text: "TODO: " + e.note})
+	case v.review:
+		result.Status = REVIEW
+		stmts = append(stmts, &Comment{Text: fmt.Sprintf("TODO: review the settings converted from %s.%s", comp.Kind, comp.ID)})
+	}
+	return result, append(stmts, blocks...)
+}
+
+// output returns the output block of a receiver, processor or connector, or nil for the other components.
+func (v *converter) output(inst instance) *Block {
+	if kind := inst.comp.Kind; kind != collector.RECEIVERS && kind != collector.PROCESSORS && kind != collector.CONNECTORS {
+		return nil
+	}
+	output := &Block{Name: "output"}
+	for _, signal := range signals {
+		var refs []Expr
+		for _, next := range v.targets(inst, signal, map[instance]bool{}) {
+			refs = append(refs, pathExpr(v.ids[next]+".input"))
+		}
+		if len(refs) > 0 {
+			output.Body = append(output.Body, &Attribute{Name: signal, Value: &Array{Elements: refs}})
+		}
+	}
+	return output
+}
+
+// targets returns the converted instances inst sends data of signal to,
+// replacing forward connectors and processors that can't be converted with
+// the instances they send data to, so the rest of the pipeline is kept.
+func (v *converter) targets(inst instance, signal string, seen map[instance]bool) []instance {
+	var found []instance
+	for _, next := range v.outputs[inst][signal] {
+		comp := next.comp
+		switch {
+		case seen[next]:
+		case comp.Kind == collector.CONNECTORS && comp.ID.Type == "forward", comp.Kind == collector.PROCESSORS && v.ids[next] == "":
+			seen[next] = true
+			found = append(found, v.targets(next, signal, seen)...)
+		case v.ids[next] != "":
+			found = append(found, next)
+		}
+	}
+	return found
+}
+
+func convertOtlpReceiver(v *converter, comp *collector.Component) Body {
+	return v.settings(comp.Get("protocols"))
+}
+
+// convertOtlpExporter moves the settings of the client to the client block,
+// and the authenticator to a reference to the handler of the auth component.
+func convertOtlpExporter(v *converter, comp *collector.Component) Body {
+	client := &Block{Name: "client"}
+	body := Body{client}
+	forEach(comp.Node, func(key string, value *yaml.Node) {
+		switch key {
+		case "sending_queue", "retry_on_failure":
+			body = append(body, v.setting(key, value)...)
+		case "headers":
+			client.Body = append(client.Body, &Attribute{Name: key, Value: v.value(value)})
+		case "auth":
+			ext, ok := v.c.Extensions[comp.GetString("auth", "authenticator")]
+			if !ok || v.ids[instance{comp: ext}] == "" {
+				v.review = true
+				return
+			}
+			client.Body = append(client.Body, &Attribute{Name: key, Value: pathExpr(v.ids[instance{comp: ext}] + ".handler")})
+		default:
+			client.Body = append(client.Body, v.setting(key, value)...)
+		}
+	})
+	return body
+}
+
+func convertBasicAuth(v *converter, comp *collector.Component) Body {
+	if comp.Has("htpasswd") {
+		v.review = true
+	}
+	return v.settings(comp.Get("client_auth"))
+}
+
+// copySettings copies the settings of components whose settings have the same names in Alloy.
+func copySettings(v *converter, comp *collector.Component) Body {
+	return v.settings(comp.Node)
+}
+
+func noSettings(v *converter, comp *collector.Component) Body {
+	return nil
+}
+
+// settings converts the settings of a component: maps become blocks, lists of
+// maps become repeated blocks and other values become attributes.
+func (v *converter) settings(n *yaml.Node) Body {
+	var body Body
+	forEach(n, func(key string, value *yaml.Node) {
+		body = append(body, v.setting(key, value)...)
+	})
+	return body
+}
+
+func (v *converter) setting(key string, value *yaml.Node) []Stmt {
+	switch {
+	case value.Kind == yaml.MappingNode:
+		return []Stmt{&Block{Name: key, Body: v.settings(value)}}
+	case value.Tag == "!!null":
+		return []Stmt{&Block{Name: key}}
+	case value.Kind == yaml.SequenceNode && len(value.Content) > 0 && value.Content[0].Kind == yaml.MappingNode:
+		// Alloy uses the singular for repeated blocks, such as action for the actions of the attributes processor.
+		var stmts []Stmt
+		for _, item := range value.Content {
+			stmts = append(stmts, &Block{Name: singular(key), Body: v.settings(item)})
+		}
+		return stmts
+	}
+	return []Stmt{&Attribute{Name: key, Value: v.value(value)}}
+}
+
+func (v *converter) value(n *yaml.Node) Expr {
+	switch n.Kind {
+	case yaml.MappingNode:
+		o := &Object{}
+		forEach(n, func(key string, value *yaml.Node) {
+			o.Fields = append(o.Fields, Field{Key: key, Value: v.value(value)})
+		})
+		return o
+	case yaml.SequenceNode:
+		a := &Array{}
+		for _, item := range n.Content {
+			a.Elements = append(a.Elements, v.value(item))
+		}
+		return a
+	case yaml.AliasNode:
+		return v.value(n.Alias)
+	}
+	switch n.Tag {
+	case "!!int", "!!float":
+		return &Literal{Kind: NUMBER, Value: n.Value}
+	case "!!bool":
+		return &Literal{Kind: BOOL, Value: strings.ToLower(n.Value)}
+	case "!!null":
+		return &Literal{Kind: NULL, Value: "null"}
+	}
+	return v.stringValue(n.Value)
+}
+
+var configReference = regexp.MustCompile(`\$\$|\$\{([^}]*)\}`)
+var envReference = regexp.MustCompile(`^(?:env:)?([A-Za-z_][A-Za-z0-9_]*)(?::-(.*))?$`)
+
+// stringValue converts a string of the collector config, replacing the
+// ${env:VAR} references with sys.env("VAR"). Other references are kept as-is.
+func (v *converter) stringValue(s string) Expr {
+	var parts []Expr
+	var text strings.Builder
+	last := 0
+	for _, m := range configReference.FindAllStringSubmatchIndex(s, -1) {
+		text.WriteString(s[last:m[0]])
+		last = m[1]
+		if m[2] < 0 {
+			text.WriteString("$")
+			continue
+		}
+		ref := envReference.FindStringSubmatch(strings.TrimSpace(s[m[2]:m[3]]))
+		if ref == nil {
+			v.review = true
+			text.WriteString(s[m[0]:m[1]])
+			continue
+		}
+		if text.Len() > 0 {
+			parts = append(parts, &Literal{Kind: STRING, Value: text.String()})
+			text.Reset()
+		}
+		var e Expr = &Call{Func: pathExpr("sys.env"), Args: []Expr{&Literal{Kind: STRING, Value: ref[1]}}}
+		if strings.Contains(ref[0], ":-") {
+			e = &Call{Func: pathExpr("coalesce"), Args: []Expr{e, &Literal{Kind: STRING, Value: ref[2]}}}
+		}
+		parts = append(parts, e)
+	}
+	text.WriteString(s[last:])
+	if text.Len() > 0 || len(parts) == 0 {
+		parts = append(parts, &Literal{Kind: STRING, Value: text.String()})
+	}
+	e := parts[0]
+	for _, part := range parts[1:] {
+		e = &Binary{Op: "+", Left: e, Right: part}
+	}
+	return e
+}
+
+// forEach calls f for every key of a map node, in order.
+func forEach(n *yaml.Node, f func(key string, value *yaml.Node)) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		f(n.Content[i].Value, n.Content[i+1])
+	}
+}
+
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// pathExpr returns the expression of a dotted path, such as otelcol.exporter.otlphttp.default.input.
+func pathExpr(path string) Expr {
+	names := strings.Split(path, ".")
+	var e Expr = &Identifier{Name: names[0]}
+	for _, name := range names[1:] {
+		e = &Access{Value: e, Name: name}
+	}
+	return e
+}
+
+var invalidLabel = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// alloyLabel returns the label of the Alloy component replacing a collector
+// component, which is its name, or default for components without a name.
+func alloyLabel(id collector.ComponentID) string {
+	if id.Name == "" {
+		return "default"
+	}
+	return invalidLabel.ReplaceAllString(id.Name, "_")
+}
+
+// logLevel returns the log level of the collector, or "" when it is not set.
+func logLevel(c *collector.Config) string {
+	if c.Service.Telemetry == nil {
+		return ""
+	}
+	var telemetry struct {
+		Logs struct {
+			Level string `yaml:"level"`
+		} `yaml:"logs"`
+	}
+	if err := c.Service.Telemetry.Decode(&telemetry); err != nil {
+		return ""
+	}
+	return strings.ToLower(telemetry.Logs.Level)
+}
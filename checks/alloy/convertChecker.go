@@ -0,0 +1,93 @@
+package alloy
+
+import (
+	"fmt"
+	"strings"
+
+	"otel-checker/checks/collector"
+	"otel-checker/checks/utils"
+)
+
+func init() {
+	utils.RegisterCheck(utils.NewCheck("alloy.convert", "alloy", nil, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		if commands.AlloyConvert || commands.AlloyConvertOutput != "" {
+			CheckConversion(report, env, commands.CollectorConfigSources(), commands.AlloyConvertOutput)
+		}
+	}))
+}
+
+const convertDocURL = "https://grafana.com/docs/alloy/latest/set-up/migrate/from-otelcol/"
+
+var kindNames = map[string]string{
+	collector.RECEIVERS:  "Receiver",
+	collector.PROCESSORS: "Processor",
+	collector.EXPORTERS:  "Exporter",
+	collector.CONNECTORS: "Connector",
+	collector.EXTENSIONS: "Extension",
+}
+
+// CheckConversion reports whether each component of the collector config loaded
+// from sources can be converted to Alloy, and writes the converted config to
+// output, unless it is empty.
+func CheckConversion(report *utils.Report, env utils.Env, sources []string, output string) {
+	c, err := collector.MergeConfig(env, sources)
+	if err != nil {
+		// Reported by the collector checks.
+		return
+	}
+
+	conv := Convert(c)
+	for _, result := range conv.Components {
+		comp := result.Component
+		f := utils.Finding{
+			Component: "Alloy",
+			Subject:   comp.Path(),
+			Location:  c.Location(comp.Key),
+		}
+		name := fmt.Sprintf("%s %s", kindNames[comp.Kind], comp.ID)
+		alloy := strings.Join(result.Alloy, ", ")
+		switch result.Status {
+		case CONVERTED:
+			f.Severity = utils.CHECKS
+			f.Message = fmt.Sprintf("%s can be converted to %s", name, alloy)
+		case REVIEW:
+			f.Severity = utils.WARNINGS
+			f.Message = fmt.Sprintf("%s can be converted to %s, but its settings can't all be converted as-is", name, alloy)
+			f.Remediation = result.Note
+			if f.Remediation == "" {
+				f.Remediation = fmt.Sprintf("Review the settings of %s in the converted config against the Alloy documentation", alloy)
+			}
+		case BUILTIN:
+			f.Severity = utils.CHECKS
+			f.Message = fmt.Sprintf("%s is not needed in Alloy. %s", name, result.Note)
+		case UNSUPPORTED:
+			f.Severity = utils.ERRORS
+			f.Message = fmt.Sprintf("%s has no Alloy equivalent, so it can't be migrated as-is", name)
+			f.Remediation = result.Note
+		}
+		if f.Severity != utils.CHECKS {
+			f.DocURL = convertDocURL
+		}
+		report.Add(f)
+	}
+
+	if output == "" {
+		return
+	}
+	if err := env.WriteFile(output, Format(conv.Config)); err != nil {
+		report.Add(utils.Finding{
+			Severity:  utils.ERRORS,
+			Component: "Alloy",
+			Subject:   output,
+			Message:   fmt.Sprintf("Could not write the converted Alloy config: %s", err),
+		})
+		return
+	}
+	report.Add(utils.Finding{
+		Severity:  utils.CHECKS,
+		Component: "Alloy",
+		Subject:   output,
+		Location:  &utils.Location{File: output},
+		Message:   fmt.Sprintf("Converted Alloy config written to %s", output),
+	})
+}
@@ -0,0 +1,170 @@
+package alloy
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/collector"
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+const collectorConfig = `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+        endpoint: 0.0.0.0:4318
+  jaeger:
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_percentage: 75
+  batch:
+  attributes/env:
+    actions:
+      - key: deployment.environment
+        value: ${env:DEPLOYMENT_ENV:-production}
+        action: upsert
+  resource:
+exporters:
+  otlphttp/grafana-cloud:
+    endpoint: https://otlp-gateway-prod-us-east-0.grafana.net/otlp
+    auth:
+      authenticator: basicauth/grafana
+    headers:
+      X-Scope-OrgID: tenant-${TENANT}
+    sending_queue:
+      queue_size: 1000
+  file:
+    path: /tmp/traces.json
+connectors:
+  forward:
+  spanmetrics:
+extensions:
+  basicauth/grafana:
+    client_auth:
+      username: "123456"
+      password: ${env:GRAFANA_CLOUD_API_KEY}
+  health_check:
+  pprof:
+service:
+  extensions: [basicauth/grafana, health_check]
+  telemetry:
+    logs:
+      level: WARN
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [memory_limiter, attributes/env, batch]
+      exporters: [forward, spanmetrics]
+    traces/export:
+      receivers: [forward]
+      exporters: [otlphttp/grafana-cloud, file]
+    metrics:
+      receivers: [otlp, spanmetrics]
+      processors: [memory_limiter, resource, batch]
+      exporters: [otlphttp/grafana-cloud]
+`
+
+func TestConvert(t *testing.T) {
+	c, err := collector.ParseConfig("config.yaml", []byte(collectorConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv := Convert(c)
+
+	var got []string
+	for _, result := range conv.Components {
+		got = append(got, result.Component.Path()+" "+result.Status+" "+strings.Join(result.Alloy, ", "))
+	}
+	want := []string{
+		"extensions.basicauth/grafana converted otelcol.auth.basic.grafana",
+		"extensions.health_check builtin ",
+		"receivers.otlp converted otelcol.receiver.otlp.default",
+		"processors.attributes/env review otelcol.processor.attributes.env",
+		"processors.batch converted otelcol.processor.batch.default_metrics, otelcol.processor.batch.default_traces",
+		"processors.memory_limiter converted otelcol.processor.memory_limiter.default_metrics, otelcol.processor.memory_limiter.default_traces",
+		"processors.resource unsupported ",
+		"connectors.forward builtin ",
+		"connectors.spanmetrics converted otelcol.connector.spanmetrics.default",
+		"exporters.file unsupported ",
+		"exporters.otlphttp/grafana-cloud converted otelcol.exporter.otlphttp.grafana_cloud",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("components =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	config := string(Format(conv.Config))
+	for _, want := range []string{
+		"logging {\n\tlevel = \"warn\"\n}",
+		"\tpassword = sys.env(\"GRAFANA_CLOUD_API_KEY\")\n",
+		"\tgrpc { }\n",
+		"\t\tvalue  = coalesce(sys.env(\"DEPLOYMENT_ENV\"), \"production\")\n",
+		"\t\tauth     = otelcol.auth.basic.grafana.handler\n",
+		"\t\t\t\"X-Scope-OrgID\" = \"tenant-\" + sys.env(\"TENANT\"),\n",
+		// The traces sent to forward are sent to the exporter directly.
+		"\t\ttraces = [otelcol.exporter.otlphttp.grafana_cloud.input, otelcol.connector.spanmetrics.default.input]\n",
+		// Processors used on several pipelines have a component per pipeline, and
+		// the metrics are sent to batch directly, as resource can't be converted.
+		"\t\tmetrics = [otelcol.processor.batch.default_metrics.input]\n",
+		"// TODO: not converted, as they have no Alloy equivalent: processors.resource, exporters.file\n",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("converted config does not contain %q:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "pprof") {
+		t.Errorf("extensions that are not enabled should not be converted:\n%s", config)
+	}
+
+	// The converted config is valid, and its signals reach an exporter.
+	alloy, err := ParseConfig("config.alloy", []byte(config))
+	if err != nil {
+		t.Fatalf("converted config is not valid: %v\n%s", err, config)
+	}
+	report := utils.NewReport()
+	checkGraph(report, alloy)
+	if got := utilstest.Subjects(report, utils.ERRORS); len(got) != 0 {
+		t.Errorf("graph errors = %v\n%s", got, config)
+	}
+}
+
+// writableFS is a fstest.MapFS the converted config can be written to.
+type writableFS struct {
+	fstest.MapFS
+}
+
+func (w writableFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	w.MapFS[name] = &fstest.MapFile{Data: data, Mode: perm}
+	return nil
+}
+
+func TestCheckConversion(t *testing.T) {
+	const output = "config.alloy"
+	fsys := writableFS{fstest.MapFS{"config.yaml": {Data: []byte(collectorConfig)}}}
+	report := utils.NewReport()
+	CheckConversion(report, utils.Env{FS: fsys}, []string{"config.yaml"}, output)
+
+	if got, want := utilstest.Subjects(report, utils.ERRORS), []string{"processors.resource", "exporters.file"}; !slices.Equal(got, want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+	if got, want := utilstest.Subjects(report, utils.WARNINGS), []string{"processors.attributes/env"}; !slices.Equal(got, want) {
+		t.Errorf("warnings = %v, want %v", got, want)
+	}
+	checks := utilstest.Subjects(report, utils.CHECKS)
+	if len(checks) != 9 || checks[8] != output {
+		t.Errorf("checks = %v", checks)
+	}
+	if f := report.BySeverity(utils.ERRORS)[1]; *f.Location != (utils.Location{File: "config.yaml", Line: 29}) {
+		t.Errorf("location = %+v", f.Location)
+	}
+	data, err := fs.ReadFile(fsys, output)
+	if err != nil || !strings.Contains(string(data), `otelcol.exporter.otlphttp "grafana_cloud" {`) {
+		t.Errorf("converted config = %s, %v", data, err)
+	}
+}
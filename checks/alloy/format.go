@@ -0,0 +1,150 @@
+package alloy
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Format returns body in the Alloy syntax, formatted like "alloy fmt" does:
+// top level statements separated by blank lines, tabs for indentation and
+// the "=" of consecutive attributes aligned.
+func Format(body Body) []byte {
+	var sb strings.Builder
+	formatBody(&sb, body, 0)
+	return []byte(sb.String())
+}
+
+func formatBody(sb *strings.Builder, body Body, depth int) {
+	indent := strings.Repeat("\t", depth)
+	for i, stmt := range body {
+		if i > 0 {
+			_, previousComment := body[i-1].(*Comment)
+			_, previousBlock := body[i-1].(*Block)
+			_, block := stmt.(*Block)
+			if depth == 0 && !previousComment || depth > 0 && (block || previousBlock) && !previousComment {
+				sb.WriteString("\n")
+			}
+		}
+		switch stmt := stmt.(type) {
+		case *Comment:
+			sb.WriteString(indent + "// " + stmt.Text + "\n")
+		case *Attribute:
+			sb.WriteString(indent + stmt.Name + strings.Repeat(" ", attributeWidth(body, i)-len(stmt.Name)) + " = ")
+			formatExpr(sb, stmt.Value, depth)
+			sb.WriteString("\n")
+		case *Block:
+			sb.WriteString(indent + stmt.Name)
+			if stmt.Label != "" {
+				sb.WriteString(" " + strconv.Quote(stmt.Label))
+			}
+			if len(stmt.Body) == 0 {
+				sb.WriteString(" { }\n")
+				continue
+			}
+			sb.WriteString(" {\n")
+			formatBody(sb, stmt.Body, depth+1)
+			sb.WriteString(indent + "}\n")
+		}
+	}
+}
+
+// attributeWidth returns the length of the longest name of the attributes
+// defined next to the one at index i, without blocks or comments in between.
+func attributeWidth(body Body, i int) int {
+	start, end := i, i
+	for start > 0 && isAttribute(body[start-1]) {
+		start--
+	}
+	for end+1 < len(body) && isAttribute(body[end+1]) {
+		end++
+	}
+	width := 0
+	for _, stmt := range body[start : end+1] {
+		width = max(width, len(stmt.(*Attribute).Name))
+	}
+	return width
+}
+
+func isAttribute(stmt Stmt) bool {
+	_, ok := stmt.(*Attribute)
+	return ok
+}
+
+func formatExpr(sb *strings.Builder, e Expr, depth int) {
+	switch e := e.(type) {
+	case *Literal:
+		if e.Kind == STRING {
+			sb.WriteString(strconv.Quote(e.Value))
+		} else {
+			sb.WriteString(e.Value)
+		}
+	case *Identifier:
+		sb.WriteString(e.Name)
+	case *Access:
+		formatExpr(sb, e.Value, depth)
+		sb.WriteString("." + e.Name)
+	case *Index:
+		formatExpr(sb, e.Value, depth)
+		sb.WriteString("[")
+		formatExpr(sb, e.Index, depth)
+		sb.WriteString("]")
+	case *Array:
+		sb.WriteString("[")
+		for i, el := range e.Elements {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			formatExpr(sb, el, depth)
+		}
+		sb.WriteString("]")
+	case *Object:
+		if len(e.Fields) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+		indent := strings.Repeat("\t", depth+1)
+		sb.WriteString("{\n")
+		for _, f := range e.Fields {
+			key := f.Key
+			if !identifier.MatchString(key) {
+				key = strconv.Quote(key)
+			}
+			sb.WriteString(indent + key + " = ")
+			formatExpr(sb, f.Value, depth+1)
+			sb.WriteString(",\n")
+		}
+		sb.WriteString(strings.Repeat("\t", depth) + "}")
+	case *Call:
+		formatExpr(sb, e.Func, depth)
+		sb.WriteString("(")
+		for i, arg := range e.Args {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			formatExpr(sb, arg, depth)
+		}
+		sb.WriteString(")")
+	case *Unary:
+		sb.WriteString(e.Op)
+		formatOperand(sb, e.Value, len(precedence)+1, depth)
+	case *Binary:
+		formatOperand(sb, e.Left, precedence[e.Op], depth)
+		sb.WriteString(" " + e.Op + " ")
+		formatOperand(sb, e.Right, precedence[e.Op]+1, depth)
+	}
+}
+
+// formatOperand formats an operand, with parentheses when it is an operation
+// with a precedence lower than minPrecedence.
+func formatOperand(sb *strings.Builder, e Expr, minPrecedence int, depth int) {
+	if b, ok := e.(*Binary); ok && precedence[b.Op] < minPrecedence {
+		sb.WriteString("(")
+		formatExpr(sb, e, depth)
+		sb.WriteString(")")
+		return
+	}
+	formatExpr(sb, e, depth)
+}
@@ -0,0 +1,68 @@
+package alloy
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	config := `logging {
+	level = "info"
+}
+
+// Sends the data to Grafana Cloud.
+otelcol.exporter.otlphttp "default" {
+	client {
+		endpoint = sys.env("OTLP_ENDPOINT") + "/otlp"
+		auth     = otelcol.auth.basic.default.handler
+		headers  = {
+			"X-Scope-OrgID" = "1",
+			tenant          = "a\"b",
+		}
+	}
+
+	sending_queue { }
+	retry_on_failure {
+		enabled = !false && (1 + 2) * 3 >= 7
+		tags    = ["a", 1, null, [true]]
+		value   = list[0].name
+	}
+}
+`
+	body, err := Parse([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Parse drops comments, so add it back.
+	body = append(body[:1], append(Body{&Comment{Text: "Sends the data to Grafana Cloud."}}, body[1:]...)...)
+
+	want := `logging {
+	level = "info"
+}
+
+// Sends the data to Grafana Cloud.
+otelcol.exporter.otlphttp "default" {
+	client {
+		endpoint = sys.env("OTLP_ENDPOINT") + "/otlp"
+		auth     = otelcol.auth.basic.default.handler
+		headers  = {
+			"X-Scope-OrgID" = "1",
+			tenant = "a\"b",
+		}
+	}
+
+	sending_queue { }
+
+	retry_on_failure {
+		enabled = !false && (1 + 2) * 3 >= 7
+		tags    = ["a", 1, null, [true]]
+		value   = list[0].name
+	}
+}
+`
+	if got := string(Format(body)); got != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+	if _, err := Parse(Format(body)); err != nil {
+		t.Errorf("formatted config is not valid: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package alloy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+const graphCheckID = "alloy.graph"
+
+// Namespaces of the standard library, which references can use without a component defining them.
+var builtinNamespaces = []string{"sys", "constants", "argument"}
+
+// Components that get their data from outside of Alloy, so other components
+// don't need to reference them. Names ending with a dot match all the
+// components starting with them.
+var sourceComponents = []string{
+	"otelcol.receiver.",
+	"prometheus.scrape",
+	"prometheus.receive_http",
+	"prometheus.operator.",
+	"loki.source.",
+	"loki.rules.",
+	"mimir.rules.",
+	"pyroscope.scrape",
+	"pyroscope.receive_http",
+	"pyroscope.ebpf",
+	"pyroscope.java",
+	"faro.receiver",
+	"beyla.ebpf",
+}
+
+// checkGraph validates the references between components: references to
+// components that are not defined, components that are not referenced,
+// signals that never reach an exporter and cycles.
+func checkGraph(report *utils.Report, c *Config) {
+	referenced := map[*Component]bool{}
+	for _, comp := range c.Components {
+		for _, ref := range comp.References() {
+			target, _ := c.Resolve(ref.Path)
+			if target != nil {
+				if target != comp {
+					referenced[target] = true
+				}
+				continue
+			}
+			namespace, _, _ := strings.Cut(ref.Path, ".")
+			if slices.Contains(builtinNamespaces, namespace) {
+				continue
+			}
+			report.Add(utils.Finding{
+				CheckID:     graphCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Alloy",
+				Subject:     comp.ID(),
+				Location:    comp.LocationOf(ref.Line),
+				Message:     fmt.Sprintf("Component %s references %s, which is not exported by any component", comp.ID(), ref.Path),
+				Remediation: "Define the component, or fix the name and label of the reference",
+				DocURL:      alloyDocURL,
+			})
+		}
+	}
+
+	for _, comp := range c.Components {
+		if referenced[comp] || isSource(comp.Name()) {
+			continue
+		}
+		message := fmt.Sprintf("Component %s is not referenced by any component, so its exports are never used", comp.ID())
+		if isOtelcol(comp, "processor", "exporter", "connector") {
+			message = fmt.Sprintf("Component %s is not referenced by any component, so it never receives data", comp.ID())
+		}
+		report.Add(utils.Finding{
+			CheckID:     graphCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Alloy",
+			Subject:     comp.ID(),
+			Location:    comp.LocationOf(comp.Block.Line),
+			Message:     message,
+			Remediation: fmt.Sprintf("Reference %s from another component, or remove it", comp.ID()),
+			DocURL:      alloyDocURL,
+		})
+	}
+
+	for _, comp := range c.Components {
+		if !isOtelcol(comp, "receiver") {
+			continue
+		}
+		output := comp.Block.Body.Block("output")
+		if output == nil {
+			continue
+		}
+		for _, signal := range signals {
+			a := output.Body.Attribute(signal)
+			if a == nil || !c.anyDefined(References(a.Value)) || c.reachesExporter(References(a.Value), signal, map[flow]bool{}) {
+				continue
+			}
+			report.Add(utils.Finding{
+				CheckID:     graphCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Alloy",
+				Subject:     comp.Path("output", signal),
+				Location:    comp.LocationOf(a.Line),
+				Message:     fmt.Sprintf("%s received by %s never reach an exporter, so they are dropped", strings.ToUpper(signal[:1])+signal[1:], comp.ID()),
+				Remediation: fmt.Sprintf("Make sure every component the %s go through sets %s on its output block, down to an otelcol.exporter component", signal, signal),
+				DocURL:      alloyDocURL,
+			})
+		}
+	}
+
+	checkCycles(report, c)
+}
+
+// anyDefined returns whether one of refs is exported by a component. The
+// other references are reported as not defined.
+func (c *Config) anyDefined(refs []Reference) bool {
+	for _, ref := range refs {
+		if comp, _ := c.Resolve(ref.Path); comp != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// flow is a signal going through a component.
+type flow struct {
+	comp   *Component
+	signal string
+}
+
+// reachesExporter returns whether data of signal sent to refs reaches an
+// otelcol exporter. Components other than otelcol processors and connectors
+// are not followed, and are assumed to send the data somewhere.
+func (c *Config) reachesExporter(refs []Reference, signal string, seen map[flow]bool) bool {
+	for _, ref := range refs {
+		next, _ := c.Resolve(ref.Path)
+		if next == nil || seen[flow{next, signal}] {
+			continue
+		}
+		seen[flow{next, signal}] = true
+		outputs := c.Outputs(next)
+		switch {
+		case isOtelcol(next, "exporter"):
+			return true
+		case isOtelcol(next, "processor"):
+			if c.reachesExporter(outputs[signal], signal, seen) {
+				return true
+			}
+		case isOtelcol(next, "connector"):
+			// Connectors can produce other signals than the ones they receive, such as spanmetrics.
+			for _, s := range signals {
+				if c.reachesExporter(outputs[s], s, seen) {
+					return true
+				}
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// checkCycles reports the components that reference each other in a cycle, which Alloy fails to load.
+func checkCycles(report *utils.Report, c *Config) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[*Component]int{}
+	var stack []*Component
+	var visit func(comp *Component)
+	visit = func(comp *Component) {
+		state[comp] = visiting
+		stack = append(stack, comp)
+		for _, ref := range comp.References() {
+			next, _ := c.Resolve(ref.Path)
+			if next == nil {
+				continue
+			}
+			switch state[next] {
+			case visiting:
+				cycle := stack[slices.Index(stack, next):]
+				ids := make([]string, 0, len(cycle)+1)
+				for _, comp := range cycle {
+					ids = append(ids, comp.ID())
+				}
+				ids = append(ids, next.ID())
+				report.Add(utils.Finding{
+					CheckID:     graphCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Alloy",
+					Subject:     comp.ID(),
+					Location:    comp.LocationOf(ref.Line),
+					Message:     fmt.Sprintf("Components reference each other in a cycle: %s. Alloy fails to load the configuration", strings.Join(ids, " -> ")),
+					Remediation: fmt.Sprintf("Remove the reference to %s from %s", ref.Path, comp.ID()),
+					DocURL:      alloyDocURL,
+				})
+			case unvisited:
+				visit(next)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[comp] = done
+	}
+	for _, comp := range c.Components {
+		if state[comp] == unvisited {
+			visit(comp)
+		}
+	}
+}
+
+func isSource(name string) bool {
+	for _, source := range sourceComponents {
+		if name == source || strings.HasSuffix(source, ".") && strings.HasPrefix(name, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOtelcol returns whether comp is an otelcol component of one of the given kinds, such as receiver.
+func isOtelcol(comp *Component, kinds ...string) bool {
+	for _, kind := range kinds {
+		if strings.HasPrefix(comp.Name(), "otelcol."+kind+".") {
+			return true
+		}
+	}
+	return false
+}
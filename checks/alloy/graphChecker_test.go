@@ -0,0 +1,143 @@
+package alloy
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckGraph(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantErrors   []string
+		wantWarnings []string
+		wantLines    []int
+	}{
+		{
+			name:   "valid",
+			config: validConfig,
+		},
+		{
+			name: "undefined references",
+			config: `
+otelcol.receiver.otlp "default" {
+  output {
+    traces = [otelcol.processor.batch.defualt.input]
+    logs   = [otelcol.exporter.otlphttp.default.input]
+  }
+}
+
+otelcol.exporter.otlphttp "default" {
+  client {
+    endpoint = sys.env("OTLP_ENDPOINT")
+    auth     = otelcol.auth.basic.default.handler
+  }
+}`,
+			wantErrors: []string{"otelcol.receiver.otlp.default", "otelcol.exporter.otlphttp.default"},
+			wantLines:  []int{4, 12},
+		},
+		{
+			name: "components not referenced",
+			config: `
+discovery.kubernetes "pods" {
+  role = "pod"
+}
+
+prometheus.scrape "default" {
+  targets    = [{"__address__" = "localhost:9090"}]
+  forward_to = []
+}
+
+otelcol.processor.batch "unused" {
+  output {}
+}`,
+			wantWarnings: []string{"discovery.kubernetes.pods", "otelcol.processor.batch.unused"},
+			wantLines:    []int{2, 11},
+		},
+		{
+			name: "signals dropped",
+			config: `
+otelcol.receiver.otlp "default" {
+  output {
+    metrics = [otelcol.processor.batch.default.input]
+    logs    = [otelcol.processor.batch.default.input]
+    traces  = [otelcol.connector.spanmetrics.default.input]
+  }
+}
+
+otelcol.processor.batch "default" {
+  output {
+    metrics = [otelcol.exporter.otlphttp.default.input]
+  }
+}
+
+otelcol.connector.spanmetrics "default" {
+  output {
+    metrics = [otelcol.processor.batch.default.input]
+  }
+}
+
+otelcol.exporter.otlphttp "default" {
+  client {
+    endpoint = "http://localhost:4318"
+  }
+}`,
+			wantErrors: []string{"otelcol.receiver.otlp.default.output.logs"},
+			wantLines:  []int{5},
+		},
+		{
+			name: "cycle",
+			config: `
+otelcol.receiver.otlp "default" {
+  output {
+    traces = [otelcol.processor.batch.a.input]
+  }
+}
+
+otelcol.processor.batch "a" {
+  output {
+    traces = [otelcol.processor.batch.b.input]
+  }
+}
+
+otelcol.processor.batch "b" {
+  output {
+    traces = [otelcol.processor.batch.a.input]
+  }
+}`,
+			wantErrors: []string{"otelcol.receiver.otlp.default.output.traces", "otelcol.processor.batch.b"},
+			wantLines:  []int{4, 16},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("config.alloy", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			checkGraph(report, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			var lines []int
+			for _, f := range append(report.BySeverity(utils.ERRORS), report.BySeverity(utils.WARNINGS)...) {
+				if f.Location == nil || f.Location.File != "config.alloy" || f.CheckID != graphCheckID {
+					t.Errorf("unexpected finding: %+v", f)
+					continue
+				}
+				lines = append(lines, f.Location.Line)
+			}
+			if !slices.Equal(lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
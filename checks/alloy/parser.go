@@ -0,0 +1,607 @@
+package alloy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Body is the content of a file or block: attributes and blocks, in the order they are defined.
+type Body []Stmt
+
+// Stmt is an *Attribute, a *Block or a *Comment.
+type Stmt interface {
+	stmtLine() int
+}
+
+// Attribute is a statement in the format name = value.
+type Attribute struct {
+	Name  string
+	Value Expr
+	Line  int
+}
+
+// Block is a statement in the format name "label" { body }, where name is
+// made of identifiers separated by dots and the label is optional.
+type Block struct {
+	Name  string
+	Label string
+	Body  Body
+	Line  int
+}
+
+// Comment is a line comment. Parse drops comments, so it only appears in the
+// bodies built to be formatted, such as converted configs.
+type Comment struct {
+	Text string
+	Line int
+}
+
+func (a *Attribute) stmtLine() int { return a.Line }
+func (b *Block) stmtLine() int     { return b.Line }
+func (c *Comment) stmtLine() int   { return c.Line }
+
+// Attribute returns the attribute of the body with the given name, or nil.
+func (b Body) Attribute(name string) *Attribute {
+	for _, stmt := range b {
+		if a, ok := stmt.(*Attribute); ok && a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Blocks returns the blocks of the body with the given name.
+func (b Body) Blocks(name string) []*Block {
+	var blocks []*Block
+	for _, stmt := range b {
+		if block, ok := stmt.(*Block); ok && block.Name == name {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// Block returns the first block of the body with the given name, or nil.
+func (b Body) Block(name string) *Block {
+	if blocks := b.Blocks(name); len(blocks) > 0 {
+		return blocks[0]
+	}
+	return nil
+}
+
+// Expr is the value of an attribute.
+type Expr interface {
+	exprLine() int
+}
+
+// Literal is a string, number, bool or null value.
+type Literal struct {
+	Kind  TokenKind
+	Value string
+	Line  int
+}
+
+type Array struct {
+	Elements []Expr
+	Line     int
+}
+
+type Object struct {
+	Fields []Field
+	Line   int
+}
+
+type Field struct {
+	Key   string
+	Value Expr
+}
+
+type Identifier struct {
+	Name string
+	Line int
+}
+
+// Access is a field access, such as value.name.
+type Access struct {
+	Value Expr
+	Name  string
+	Line  int
+}
+
+type Index struct {
+	Value Expr
+	Index Expr
+	Line  int
+}
+
+type Call struct {
+	Func Expr
+	Args []Expr
+	Line int
+}
+
+type Unary struct {
+	Op    string
+	Value Expr
+	Line  int
+}
+
+type Binary struct {
+	Op    string
+	Left  Expr
+	Right Expr
+	Line  int
+}
+
+func (e *Literal) exprLine() int    { return e.Line }
+func (e *Array) exprLine() int      { return e.Line }
+func (e *Object) exprLine() int     { return e.Line }
+func (e *Identifier) exprLine() int { return e.Line }
+func (e *Access) exprLine() int     { return e.Line }
+func (e *Index) exprLine() int      { return e.Line }
+func (e *Call) exprLine() int       { return e.Line }
+func (e *Unary) exprLine() int      { return e.Line }
+func (e *Binary) exprLine() int     { return e.Line }
+
+// Line returns the line an expression starts on.
+func Line(e Expr) int {
+	return e.exprLine()
+}
+
+// Path returns the dotted path of an identifier or a chain of field accesses,
+// such as otelcol.exporter.otlphttp.default.input, or "" for other expressions.
+func Path(e Expr) string {
+	switch e := e.(type) {
+	case *Identifier:
+		return e.Name
+	case *Access:
+		if p := Path(e.Value); p != "" {
+			return p + "." + e.Name
+		}
+	}
+	return ""
+}
+
+// Reference is a path to a value exported by a component or to the standard library, used in an expression.
+type Reference struct {
+	Path string
+	Line int
+}
+
+// References returns the references used in an expression. The functions
+// that are called, such as sys.env, are not included.
+func References(e Expr) []Reference {
+	var refs []Reference
+	var walk func(e Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case *Identifier, *Access:
+			if p := Path(e); p != "" {
+				refs = append(refs, Reference{Path: p, Line: Line(e)})
+				return
+			}
+			if a, ok := e.(*Access); ok {
+				walk(a.Value)
+			}
+		case *Array:
+			for _, el := range e.Elements {
+				walk(el)
+			}
+		case *Object:
+			for _, f := range e.Fields {
+				walk(f.Value)
+			}
+		case *Index:
+			walk(e.Value)
+			walk(e.Index)
+		case *Call:
+			if Path(e.Func) == "" {
+				walk(e.Func)
+			}
+			for _, arg := range e.Args {
+				walk(arg)
+			}
+		case *Unary:
+			walk(e.Value)
+		case *Binary:
+			walk(e.Left)
+			walk(e.Right)
+		}
+	}
+	walk(e)
+	return refs
+}
+
+// SyntaxError is returned by Parse when a file is not valid Alloy syntax.
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Parse parses the content of an Alloy configuration file.
+func Parse(data []byte) (Body, error) {
+	tokens, err := tokenize(string(data))
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	body, err := p.body(EOF)
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+type TokenKind int
+
+const (
+	EOF TokenKind = iota
+	IDENT
+	STRING
+	NUMBER
+	BOOL
+	NULL
+	PUNCT
+)
+
+type token struct {
+	kind  TokenKind
+	value string
+	line  int
+}
+
+// Punctuation and operators, longest first.
+var puncts = []string{"==", "!=", "<=", ">=", "&&", "||", "{", "}", "[", "]", "(", ")", ",", ".", "=", "<", ">", "+", "-", "*", "/", "%", "^", "!", ":"}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, &SyntaxError{Line: line, Message: "unterminated comment"}
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+				if j < len(src) && src[j] == '\n' {
+					return nil, &SyntaxError{Line: line, Message: "unterminated string"}
+				}
+			}
+			if j >= len(src) {
+				return nil, &SyntaxError{Line: line, Message: "unterminated string"}
+			}
+			value, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, &SyntaxError{Line: line, Message: fmt.Sprintf("invalid string %s", src[i:j+1])}
+			}
+			tokens = append(tokens, token{STRING, value, line})
+			i = j + 1
+		case c == '`':
+			end := strings.IndexByte(src[i+1:], '`')
+			if end < 0 {
+				return nil, &SyntaxError{Line: line, Message: "unterminated raw string"}
+			}
+			value := src[i+1 : i+1+end]
+			tokens = append(tokens, token{STRING, value, line})
+			line += strings.Count(value, "\n")
+			i += end + 2
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.' || src[j] == 'e' || src[j] == 'E' || ((src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, token{NUMBER, src[i:j], line})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(src) && (src[j] == '_' || isDigit(src[j]) || unicode.IsLetter(rune(src[j]))) {
+				j++
+			}
+			word := src[i:j]
+			switch word {
+			case "true", "false":
+				tokens = append(tokens, token{BOOL, word, line})
+			case "null":
+				tokens = append(tokens, token{NULL, word, line})
+			default:
+				tokens = append(tokens, token{IDENT, word, line})
+			}
+			i = j
+		default:
+			matched := false
+			for _, p := range puncts {
+				if strings.HasPrefix(src[i:], p) {
+					tokens = append(tokens, token{PUNCT, p, line})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &SyntaxError{Line: line, Message: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	return append(tokens, token{EOF, "", line}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != EOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(value string) bool {
+	t := p.peek()
+	return t.kind == PUNCT && t.value == value
+}
+
+func (p *parser) expect(value string) (token, error) {
+	t := p.next()
+	if t.kind != PUNCT || t.value != value {
+		return t, unexpected(t, fmt.Sprintf("%q", value))
+	}
+	return t, nil
+}
+
+func unexpected(t token, expected string) error {
+	found := fmt.Sprintf("%q", t.value)
+	if t.kind == EOF {
+		found = "end of file"
+	}
+	return &SyntaxError{Line: t.line, Message: fmt.Sprintf("expected %s, found %s", expected, found)}
+}
+
+// body parses statements until the closing token, which is "}" or EOF.
+func (p *parser) body(closing TokenKind) (Body, error) {
+	var body Body
+	for {
+		t := p.peek()
+		if closing == EOF && t.kind == EOF || closing == PUNCT && p.isPunct("}") {
+			return body, nil
+		}
+		if t.kind == EOF {
+			return nil, unexpected(t, `"}"`)
+		}
+		stmt, err := p.stmt()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, stmt)
+	}
+}
+
+func (p *parser) stmt() (Stmt, error) {
+	first := p.next()
+	if first.kind != IDENT {
+		return nil, unexpected(first, "an attribute or block name")
+	}
+	name := first.value
+	for p.isPunct(".") {
+		p.next()
+		t := p.next()
+		if t.kind != IDENT {
+			return nil, unexpected(t, "an identifier")
+		}
+		name += "." + t.value
+	}
+
+	if p.isPunct("=") && !strings.Contains(name, ".") {
+		p.next()
+		value, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		return &Attribute{Name: name, Value: value, Line: first.line}, nil
+	}
+
+	block := &Block{Name: name, Line: first.line}
+	if p.peek().kind == STRING {
+		block.Label = p.next().value
+	}
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	body, err := p.body(PUNCT)
+	if err != nil {
+		return nil, err
+	}
+	p.next()
+	block.Body = body
+	return block, nil
+}
+
+// Precedence of the binary operators.
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+	"^": 6,
+}
+
+func (p *parser) expr(minPrecedence int) (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.value]
+		if t.kind != PUNCT || !ok || prec <= minPrecedence {
+			return left, nil
+		}
+		p.next()
+		// ^ is right associative.
+		next := prec
+		if t.value == "^" {
+			next--
+		}
+		right, err := p.expr(next)
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: t.value, Left: left, Right: right, Line: t.line}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.isPunct("!") || p.isPunct("-") {
+		t := p.next()
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: t.value, Value: value, Line: t.line}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (Expr, error) {
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isPunct("."):
+			t := p.next()
+			name := p.next()
+			if name.kind != IDENT {
+				return nil, unexpected(name, "an identifier")
+			}
+			e = &Access{Value: e, Name: name.value, Line: t.line}
+		case p.isPunct("["):
+			t := p.next()
+			index, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			e = &Index{Value: e, Index: index, Line: t.line}
+		case p.isPunct("("):
+			t := p.next()
+			args, err := p.list(")")
+			if err != nil {
+				return nil, err
+			}
+			e = &Call{Func: e, Args: args, Line: t.line}
+		default:
+			return e, nil
+		}
+	}
+}
+
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case IDENT:
+		return &Identifier{Name: t.value, Line: t.line}, nil
+	case STRING, NUMBER, BOOL, NULL:
+		return &Literal{Kind: t.kind, Value: t.value, Line: t.line}, nil
+	case PUNCT:
+		switch t.value {
+		case "(":
+			e, err := p.expr(0)
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.expect(")")
+			return e, err
+		case "[":
+			elements, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			return &Array{Elements: elements, Line: t.line}, nil
+		case "{":
+			return p.object(t)
+		}
+	}
+	return nil, unexpected(t, "a value")
+}
+
+// list parses expressions separated by commas, with an optional trailing comma, until closing.
+func (p *parser) list(closing string) ([]Expr, error) {
+	var elements []Expr
+	for !p.isPunct(closing) {
+		e, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	_, err := p.expect(closing)
+	return elements, err
+}
+
+func (p *parser) object(open token) (Expr, error) {
+	o := &Object{Line: open.line}
+	for !p.isPunct("}") {
+		key := p.next()
+		if key.kind != IDENT && key.kind != STRING {
+			return nil, unexpected(key, "an object key")
+		}
+		if !p.isPunct("=") && !p.isPunct(":") {
+			return nil, unexpected(p.peek(), `"="`)
+		}
+		p.next()
+		value, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		o.Fields = append(o.Fields, Field{Key: key.value, Value: value})
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	_, err := p.expect("}")
+	return o, err
+}
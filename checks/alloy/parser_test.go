@@ -0,0 +1,102 @@
+package alloy
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	body, err := Parse([]byte(`
+// Receives OTLP data from the applications.
+otelcol.receiver.otlp "default" {
+  grpc {}
+  http {
+    endpoint = "0.0.0.0:4318"
+  }
+
+  output {
+    metrics = [otelcol.processor.batch.default.input]
+    traces  = [otelcol.processor.batch.default.input, otelcol.connector.spanmetrics.default.input]
+  }
+}
+
+/* Sends the data to Grafana Cloud. */
+otelcol.exporter.otlphttp "grafana_cloud" {
+  client {
+    endpoint = sys.env("GRAFANA_CLOUD_OTLP_ENDPOINT") + "/otlp"
+    auth     = otelcol.auth.basic.grafana_cloud.handler
+    headers  = {"X-Scope-OrgID" = "1", tenant: ` + "`raw`" + `}
+  }
+  retry_on_failure { max_elapsed_time = "1m" }
+  sending_queue { enabled = !false && 1 + 2 * 3 >= 7 }
+}
+
+logging {
+  level = "info"
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != 3 {
+		t.Fatalf("got %d statements, want 3", len(body))
+	}
+
+	receiver := body.Blocks("otelcol.receiver.otlp")
+	if len(receiver) != 1 || receiver[0].Label != "default" || receiver[0].Line != 3 {
+		t.Fatalf("unexpected receiver: %+v", receiver)
+	}
+	http := receiver[0].Body.Block("http")
+	if endpoint := http.Body.Attribute("endpoint"); endpoint == nil || endpoint.Line != 6 || endpoint.Value.(*Literal).Value != "0.0.0.0:4318" {
+		t.Errorf("unexpected endpoint: %+v", endpoint)
+	}
+	var refs []string
+	for _, r := range References(receiver[0].Body.Block("output").Body.Attribute("traces").Value) {
+		refs = append(refs, r.Path)
+	}
+	if want := []string{"otelcol.processor.batch.default.input", "otelcol.connector.spanmetrics.default.input"}; !slices.Equal(refs, want) {
+		t.Errorf("references = %v, want %v", refs, want)
+	}
+
+	client := body.Blocks("otelcol.exporter.otlphttp")[0].Body.Block("client")
+	if got := References(client.Body.Attribute("endpoint").Value); len(got) != 0 {
+		t.Errorf("function names are not references, got %v", got)
+	}
+	if got := Path(client.Body.Attribute("auth").Value); got != "otelcol.auth.basic.grafana_cloud.handler" {
+		t.Errorf("auth = %q", got)
+	}
+	headers := client.Body.Attribute("headers").Value.(*Object)
+	if len(headers.Fields) != 2 || headers.Fields[0].Key != "X-Scope-OrgID" || headers.Fields[1].Value.(*Literal).Value != "raw" {
+		t.Errorf("unexpected headers: %+v", headers.Fields)
+	}
+	if body.Block("logging") == nil {
+		t.Error("logging block not found")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		wantLine int
+	}{
+		{name: "unclosed block", config: "otelcol.receiver.otlp \"default\" {\n  grpc {}\n", wantLine: 3},
+		{name: "missing value", config: "logging {\n  level =\n}\n", wantLine: 3},
+		{name: "unterminated string", config: "logging {\n  level = \"info\n}\n", wantLine: 2},
+		{name: "unterminated comment", config: "/* logging {}\n", wantLine: 1},
+		{name: "invalid character", config: "logging {\n  level = #info\n}\n", wantLine: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a syntax error, got %v", err)
+			}
+			if syntaxErr.Line != tt.wantLine {
+				t.Errorf("line = %d, want %d (%s)", syntaxErr.Line, tt.wantLine, syntaxErr.Message)
+			}
+		})
+	}
+}
@@ -1,3 +1,149 @@
 package beyla
 
-func CheckBeylaSetup(messages *map[string][]string, language string) {}
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+func init() {
+	utils.RegisterCheck(utils.NewCheck("beyla", "beyla", nil, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		configPath := commands.BeylaConfigPath
+		if configPath == "" {
+			configPath = env.Getenv("BEYLA_CONFIG_PATH")
+		}
+		CheckBeylaSetup(report, env, commands.Language, configPath, commands.HostRoot())
+	}))
+}
+
+const configCheckID = "beyla.config"
+
+const optionsDocURL = "https://grafana.com/docs/beyla/latest/configure/options/"
+
+// CheckBeylaSetup checks the environment variables of Beyla, its YAML config
+// file when configPath is not empty, and the prerequisites and processes of
+// the host whose /proc and /sys are under hostRoot.
+func CheckBeylaSetup(report *utils.Report, env utils.Env, language string, configPath string, hostRoot string) {
+	var c *Config
+	if configPath != "" {
+		var err error
+		if c, err = LoadConfig(env, configPath); err != nil {
+			report.Add(utils.Finding{
+				CheckID:   configCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Beyla",
+				Subject:   configPath,
+				Location:  &utils.Location{File: configPath},
+				Message:   fmt.Sprintf("Could not load the Beyla configuration %s: %s", configPath, err),
+				DocURL:    optionsDocURL,
+			})
+		}
+	}
+
+	checkEnvVars(report, env, c)
+	checkDiscovery(report, env, c)
+	checkExports(report, c)
+	checkRoutes(report, c)
+	checkHost(report, env, hostRoot, networkMetrics(env, c))
+	checkProcesses(report, env, c, hostRoot)
+}
+
+// checkEnvVars checks the service name and Grafana Cloud settings, which can
+// be set with environment variables or in the config file c, which can be nil.
+func checkEnvVars(report *utils.Report, env utils.Env, c *Config) {
+	if name := setting(env, c, "BEYLA_SERVICE_NAME", "service_name"); name.Value == "" {
+		report.Add(utils.Finding{
+			CheckID:   configCheckID,
+			Severity:  utils.WARNINGS,
+			Component: "Beyla",
+			Subject:   "BEYLA_SERVICE_NAME",
+			Message:   "It's recommended the environment variable BEYLA_SERVICE_NAME to be set to your service name",
+			DocURL:    optionsDocURL,
+		})
+	} else {
+		report.Add(utils.Finding{
+			CheckID:   configCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Beyla",
+			Subject:   name.Subject,
+			Location:  name.Location,
+			Message:   fmt.Sprintf("%s is set", name.Subject),
+		})
+	}
+
+	submit := setting(env, c, "GRAFANA_CLOUD_SUBMIT", "grafana", "otlp", "cloud_submit")
+	if submit.Value == "" {
+		report.Add(utils.Finding{
+			CheckID:     configCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     submit.Subject,
+			Location:    submit.Location,
+			Message:     "GRAFANA_CLOUD_SUBMIT must be set to 'metrics' and/or 'traces'",
+			Remediation: `Run 'export GRAFANA_CLOUD_SUBMIT="metrics,traces"'`,
+			DocURL:      optionsDocURL,
+		})
+	} else if invalid := invalidSubmitValues(submit.Value); len(invalid) > 0 {
+		report.Add(utils.Finding{
+			CheckID:     configCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     submit.Subject,
+			Location:    submit.Location,
+			Message:     fmt.Sprintf("%s contains %s, but can only contain 'metrics' and 'traces'", submit.Subject, strings.Join(invalid, ", ")),
+			Remediation: fmt.Sprintf("Set %s to 'metrics' and/or 'traces'", submit.Subject),
+			DocURL:      optionsDocURL,
+		})
+	} else {
+		report.Add(utils.Finding{
+			CheckID:   configCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Beyla",
+			Subject:   submit.Subject,
+			Location:  submit.Location,
+			Message:   fmt.Sprintf("%s is set correctly", submit.Subject),
+		})
+	}
+
+	for _, s := range []struct {
+		envVar string
+		key    string
+	}{
+		{"GRAFANA_CLOUD_INSTANCE_ID", "cloud_instance_id"},
+		{"GRAFANA_CLOUD_API_KEY", "cloud_api_key"},
+	} {
+		if value := setting(env, c, s.envVar, "grafana", "otlp", s.key); value.Value == "" {
+			report.Add(utils.Finding{
+				CheckID:     configCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     value.Subject,
+				Location:    value.Location,
+				Message:     fmt.Sprintf("%s must be set", s.envVar),
+				Remediation: "Copy it from the OpenTelemetry section of your Grafana Cloud stack",
+				DocURL:      optionsDocURL,
+			})
+		} else {
+			report.Add(utils.Finding{
+				CheckID:   configCheckID,
+				Severity:  utils.CHECKS,
+				Component: "Beyla",
+				Subject:   value.Subject,
+				Location:  value.Location,
+				Message:   fmt.Sprintf("%s is set", value.Subject),
+			})
+		}
+	}
+}
+
+func invalidSubmitValues(submit string) []string {
+	var invalid []string
+	for _, value := range strings.Split(submit, ",") {
+		if value = strings.TrimSpace(value); !slices.Contains([]string{"metrics", "traces"}, value) {
+			invalid = append(invalid, fmt.Sprintf("'%s'", value))
+		}
+	}
+	return invalid
+}
@@ -0,0 +1,196 @@
+package beyla
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+var validEnv = map[string]string{
+	"BEYLA_SERVICE_NAME":        "my-service",
+	"BEYLA_OPEN_PORT":           "8080",
+	"GRAFANA_CLOUD_SUBMIT":      "metrics,traces",
+	"GRAFANA_CLOUD_INSTANCE_ID": "123456",
+	"GRAFANA_CLOUD_API_KEY":     "glc_abc",
+}
+
+// with returns validEnv with the values of pairs of keys and values.
+func with(pairs ...string) map[string]string {
+	env := map[string]string{}
+	for k, v := range validEnv {
+		env[k] = v
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		env[pairs[i]] = pairs[i+1]
+	}
+	return env
+}
+
+// tcpHeader is the first line of /proc/<pid>/net/tcp.
+const tcpHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+// validHost is the /proc and /sys of a host meeting the prerequisites of Beyla,
+// with a process running with all capabilities, and my-service listening on 8080.
+var validHost = fstest.MapFS{
+	"proc/100/exe":           {Data: []byte("/usr/local/bin/my-service"), Mode: fs.ModeSymlink},
+	"proc/100/fd/3":          {Data: []byte("socket:[1000]"), Mode: fs.ModeSymlink},
+	"proc/100/net/tcp":       {Data: []byte(tcpHeader + "   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1000 1 0000000000000000 100 0 0 10 0\n")},
+	"proc/version":           {Data: []byte("Linux version 6.5.0-1015-azure (buildd@lcy02-amd64-055) (x86_64-linux-gnu-gcc-12) #15~22.04.1-Ubuntu SMP\n")},
+	"proc/self/status":       {Data: []byte("Name:\tbeyla\nCapInh:\t0000000000000000\nCapPrm:\t000001ffffffffff\nCapEff:\t000001ffffffffff\n")},
+	"sys/kernel/btf/vmlinux": {Data: []byte("BTF")},
+}
+
+// withHost returns files with the ones of validHost under root.
+func withHost(root string, files fstest.MapFS) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, f := range files {
+		fsys[name] = f
+	}
+	for name, f := range validHost {
+		fsys[path.Join(root, name)] = f
+	}
+	return fsys
+}
+
+const validConfig = `
+service_name: my-service
+discovery:
+  services:
+    - open_ports: 8080
+grafana:
+  otlp:
+    cloud_zone: prod-us-east-0
+    cloud_instance_id: "123456"
+    cloud_api_key: ${GRAFANA_CLOUD_API_KEY}
+    cloud_submit: [metrics, traces]
+otel_traces_export:
+  endpoint: https://otlp-gateway-prod-us-east-0.grafana.net/otlp
+routes:
+  patterns:
+    - /users/{id}
+  unmatched: heuristic
+`
+
+func TestCheckBeylaSetup(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		files        fstest.MapFS
+		configPath   string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "valid environment",
+			env:  validEnv,
+		},
+		{
+			name:         "empty environment",
+			env:          map[string]string{},
+			wantErrors:   []string{"GRAFANA_CLOUD_SUBMIT", "GRAFANA_CLOUD_INSTANCE_ID", "GRAFANA_CLOUD_API_KEY", "BEYLA_OPEN_PORT"},
+			wantWarnings: []string{"BEYLA_SERVICE_NAME"},
+		},
+		{
+			name:       "invalid open port",
+			env:        with("BEYLA_OPEN_PORT", "8080-80"),
+			wantErrors: []string{"BEYLA_OPEN_PORT"},
+		},
+		{
+			name: "executable name instead of open port",
+			env:  with("BEYLA_OPEN_PORT", "", "BEYLA_EXECUTABLE_NAME", "my-service"),
+		},
+		{
+			name:       "invalid submit",
+			env:        with("GRAFANA_CLOUD_SUBMIT", "traces,logs"),
+			wantErrors: []string{"GRAFANA_CLOUD_SUBMIT"},
+		},
+		{
+			name:       "settings in the config file",
+			env:        map[string]string{},
+			files:      fstest.MapFS{"beyla-config.yml": {Data: []byte(validConfig)}},
+			configPath: "beyla-config.yml",
+		},
+		{
+			name:       "environment variables override the config file",
+			env:        map[string]string{"BEYLA_OPEN_PORT": "http", "GRAFANA_CLOUD_SUBMIT": "logs"},
+			files:      fstest.MapFS{"beyla-config.yml": {Data: []byte(validConfig)}},
+			configPath: "beyla-config.yml",
+			wantErrors: []string{"GRAFANA_CLOUD_SUBMIT", "BEYLA_OPEN_PORT"},
+		},
+		{
+			name:       "missing config file",
+			env:        validEnv,
+			files:      fstest.MapFS{},
+			configPath: "beyla-config.yml",
+			wantErrors: []string{"beyla-config.yml"},
+		},
+		{
+			name:       "invalid config file",
+			env:        validEnv,
+			files:      fstest.MapFS{"beyla-config.yml": {Data: []byte("- open_port: 8080\n")}},
+			configPath: "beyla-config.yml",
+			wantErrors: []string{"beyla-config.yml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(tt.env), FS: withHost(".", tt.files)}
+			CheckBeylaSetup(report, env, "go", tt.configPath, "/")
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestCheckBeylaSetupLocations(t *testing.T) {
+	env := utils.Env{
+		Context: context.Background(),
+		Getenv:  utils.MapGetenv(nil),
+		FS:      withHost(".", fstest.MapFS{"beyla-config.yml": {Data: []byte(validConfig)}}),
+	}
+	report := utils.NewReport()
+	CheckBeylaSetup(report, env, "go", "beyla-config.yml", "/")
+
+	for _, f := range report.BySeverity(utils.CHECKS) {
+		if f.Subject == "grafana.otlp.cloud_submit" {
+			if *f.Location != (utils.Location{File: "beyla-config.yml", Line: 11}) {
+				t.Errorf("location = %+v", f.Location)
+			}
+			return
+		}
+	}
+	t.Error("no successful check for grafana.otlp.cloud_submit")
+}
+
+func TestCheckBeylaSetupCheckIDs(t *testing.T) {
+	env := utils.Env{
+		Context: context.Background(),
+		Getenv:  utils.MapGetenv(nil),
+		FS:      withHost(".", fstest.MapFS{"beyla-config.yml": {Data: []byte(validConfig)}}),
+	}
+	report := utils.NewReport()
+	CheckBeylaSetup(report, env, "go", "beyla-config.yml", "/")
+
+	var got []string
+	for _, f := range report.Findings {
+		if !slices.Contains(got, f.CheckID) {
+			got = append(got, f.CheckID)
+		}
+	}
+	want := []string{configCheckID, discoveryCheckID, exportCheckID, hostCheckID, processesCheckID}
+	if !slices.Equal(got, want) {
+		t.Errorf("check IDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+package beyla
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a Beyla YAML config file.
+type Config struct {
+	File string
+	// Root is the mapping node of the top level of the file.
+	Root *yaml.Node
+}
+
+// LoadConfig reads and parses the Beyla config file at file.
+func LoadConfig(env utils.Env, file string) (*Config, error) {
+	data, err := env.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(file, data)
+}
+
+// ParseConfig parses the content of a Beyla config file.
+func ParseConfig(file string, data []byte) (*Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	if len(doc.Content) > 0 && !isNull(doc.Content[0]) {
+		root = doc.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of settings", root.Line)
+	}
+	return &Config{File: file, Root: root}, nil
+}
+
+// Get returns the node at path, or nil when it doesn't exist or c is nil.
+func (c *Config) Get(path ...string) *yaml.Node {
+	if c == nil {
+		return nil
+	}
+	n := c.Root
+	for _, key := range path {
+		if n = lookup(n, key); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// GetString returns the value at path, or "" when it doesn't exist. The
+// values of a list are joined with ",", as in the environment variables.
+func (c *Config) GetString(path ...string) string {
+	n := c.Get(path...)
+	if n == nil || isNull(n) {
+		return ""
+	}
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return n.Value
+	case yaml.SequenceNode:
+		var values []string
+		for _, item := range n.Content {
+			if item.Kind == yaml.ScalarNode {
+				values = append(values, item.Value)
+			}
+		}
+		return strings.Join(values, ",")
+	}
+	return ""
+}
+
+// Location returns the location of a node of the config, or nil when n is nil.
+func (c *Config) Location(n *yaml.Node) *utils.Location {
+	if c == nil || n == nil {
+		return nil
+	}
+	return &utils.Location{File: c.File, Line: n.Line}
+}
+
+// Criterion is a value used to select processes, with where it is defined.
+type Criterion struct {
+	Value string
+	// Subject is the YAML path or the environment variable the value comes from.
+	Subject  string
+	Location *utils.Location
+}
+
+// Selector selects processes to instrument, or to exclude, by the ports they
+// open, their executable path or their Kubernetes metadata. A process is
+// selected when it matches all the criteria of the selector.
+type Selector struct {
+	// Subject is the YAML path of the selector, such as discovery.services[0],
+	// or the settings it is made of, such as BEYLA_OPEN_PORT.
+	Subject  string
+	Location *utils.Location
+	// Name is the service name given to the processes selected.
+	Name string
+	// OpenPorts is a list of ports and port ranges, such as "80,8000-8999".
+	OpenPorts Criterion
+	// ExePath is a regular expression, or a glob when Glob is set.
+	ExePath    Criterion
+	Kubernetes []Criterion
+	Glob       bool
+	Exclude    bool
+}
+
+// Empty returns whether the selector has no criteria.
+func (s Selector) Empty() bool {
+	return s.OpenPorts.Value == "" && s.ExePath.Value == "" && len(s.Kubernetes) == 0
+}
+
+// Lists of selectors of the discovery section, and whether they use globs and
+// exclude processes.
+var selectorLists = []struct {
+	key     string
+	glob    bool
+	exclude bool
+}{
+	{key: "services"},
+	{key: "exclude_services", exclude: true},
+	{key: "instrument", glob: true},
+	{key: "exclude_instrument", glob: true, exclude: true},
+}
+
+// Selectors returns the selectors of the discovery section of c, which can be
+// nil, after the one made of BEYLA_OPEN_PORT and BEYLA_EXECUTABLE_NAME, or of
+// the open_port and executable_name settings they override.
+func Selectors(env utils.Env, c *Config) []Selector {
+	var selectors []Selector
+	top := Selector{
+		Name:      setting(env, c, "BEYLA_SERVICE_NAME", "service_name").Value,
+		OpenPorts: setting(env, c, "BEYLA_OPEN_PORT", "open_port"),
+		ExePath:   setting(env, c, "BEYLA_EXECUTABLE_NAME", "executable_name"),
+	}
+	if !top.Empty() {
+		var subjects []string
+		for _, criterion := range []Criterion{top.OpenPorts, top.ExePath} {
+			if criterion.Value != "" {
+				subjects = append(subjects, criterion.Subject)
+			}
+		}
+		top.Subject = strings.Join(subjects, " and ")
+		selectors = append(selectors, top)
+	}
+
+	for _, list := range selectorLists {
+		n := c.Get("discovery", list.key)
+		if n == nil || n.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, item := range n.Content {
+			s := Selector{
+				Subject:  fmt.Sprintf("discovery.%s[%d]", list.key, i),
+				Location: c.Location(item),
+				Glob:     list.glob,
+				Exclude:  list.exclude,
+			}
+			if item.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(item.Content); j += 2 {
+					key, value := item.Content[j].Value, item.Content[j+1]
+					criterion := Criterion{Value: scalar(value), Subject: s.Subject + "." + key, Location: c.Location(value)}
+					switch {
+					case key == "name":
+						s.Name = criterion.Value
+					case key == "open_ports":
+						s.OpenPorts = criterion
+					case key == "exe_path":
+						s.ExePath = criterion
+					case strings.HasPrefix(key, "k8s_"):
+						s.Kubernetes = append(s.Kubernetes, criterion)
+					}
+				}
+			}
+			selectors = append(selectors, s)
+		}
+	}
+	return selectors
+}
+
+// setting returns the value of an environment variable, or of the setting at
+// path of c it overrides when it is not set.
+func setting(env utils.Env, c *Config, envVar string, path ...string) Criterion {
+	if value := env.Getenv(envVar); value != "" {
+		return Criterion{Value: value, Subject: envVar}
+	}
+	if value := c.GetString(path...); value != "" {
+		return Criterion{Value: value, Subject: strings.Join(path, "."), Location: c.Location(c.Get(path...))}
+	}
+	return Criterion{Subject: envVar}
+}
+
+// PortRange is a range of ports, from Start to End included.
+type PortRange struct {
+	Start int
+	End   int
+}
+
+// Contains returns whether port is in the range.
+func (r PortRange) Contains(port int) bool {
+	return port >= r.Start && port <= r.End
+}
+
+// ParsePorts parses a comma separated list of ports and port ranges, such as
+// "80,443,8000-8999", as accepted by BEYLA_OPEN_PORT.
+func ParsePorts(ports string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, part := range strings.Split(ports, ",") {
+		part = strings.TrimSpace(part)
+		start, end, isRange := strings.Cut(part, "-")
+		r := PortRange{}
+		var err error
+		if r.Start, err = parsePort(start); err != nil {
+			return nil, err
+		}
+		r.End = r.Start
+		if isRange {
+			if r.End, err = parsePort(end); err != nil {
+				return nil, err
+			}
+			if r.End < r.Start {
+				return nil, fmt.Errorf("port range %q ends before it starts", part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func parsePort(port string) (int, error) {
+	port = strings.TrimSpace(port)
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("%q is not a port between 1 and 65535", port)
+	}
+	return p, nil
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// lookup returns the value of key in a mapping node, or nil when it doesn't exist.
+func lookup(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalar returns the value of a scalar node, or "" for the other nodes.
+func scalar(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode || isNull(n) {
+		return ""
+	}
+	return n.Value
+}
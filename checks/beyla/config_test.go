@@ -0,0 +1,89 @@
+package beyla
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		ports   string
+		want    []PortRange
+		wantErr bool
+	}{
+		{ports: "8080", want: []PortRange{{8080, 8080}}},
+		{ports: "80, 443,8000-8999", want: []PortRange{{80, 80}, {443, 443}, {8000, 8999}}},
+		{ports: "8080-8080", want: []PortRange{{8080, 8080}}},
+		{ports: "http", wantErr: true},
+		{ports: "0", wantErr: true},
+		{ports: "65536", wantErr: true},
+		{ports: "8080-80", wantErr: true},
+		{ports: "8080-", wantErr: true},
+		{ports: "80,,443", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ports, func(t *testing.T) {
+			got, err := ParsePorts(tt.ports)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParsePorts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	c, err := ParseConfig("beyla-config.yml", []byte(`
+open_port: 8080
+discovery:
+  services:
+    - name: api
+      exe_path: api$
+      k8s_namespace: prod
+  exclude_services:
+    - exe_path: ".*/sidecar"
+  instrument:
+    - open_ports: 9000-9100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := utils.Env{Getenv: utils.MapGetenv(map[string]string{"BEYLA_EXECUTABLE_NAME": "java"})}
+
+	var got []string
+	for _, s := range Selectors(env, c) {
+		got = append(got, s.Subject+" "+s.OpenPorts.Subject+"="+s.OpenPorts.Value+" "+s.ExePath.Subject+"="+s.ExePath.Value)
+	}
+	want := []string{
+		"open_port and BEYLA_EXECUTABLE_NAME open_port=8080 BEYLA_EXECUTABLE_NAME=java",
+		"discovery.services[0] = discovery.services[0].exe_path=api$",
+		"discovery.exclude_services[0] = discovery.exclude_services[0].exe_path=.*/sidecar",
+		"discovery.instrument[0] discovery.instrument[0].open_ports=9000-9100 =",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("selectors = %q, want %q", got, want)
+	}
+
+	selectors := Selectors(env, c)
+	if s := selectors[1]; s.Name != "api" || len(s.Kubernetes) != 1 || s.Kubernetes[0].Value != "prod" || *s.Location != (utils.Location{File: "beyla-config.yml", Line: 5}) {
+		t.Errorf("selector = %+v", s)
+	}
+	if !selectors[2].Exclude || selectors[2].Glob || !selectors[3].Glob || selectors[3].Exclude {
+		t.Errorf("selectors = %+v", selectors)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, config := range []string{"open_port: [8080", "- 8080", "8080"} {
+		if _, err := ParseConfig("beyla-config.yml", []byte(config)); err == nil {
+			t.Errorf("ParseConfig(%q) returned no error", config)
+		}
+	}
+	if c, err := ParseConfig("beyla-config.yml", nil); err != nil || c.Get("open_port") != nil {
+		t.Errorf("ParseConfig() of an empty file = %+v, %v", c, err)
+	}
+}
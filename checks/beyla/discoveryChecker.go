@@ -0,0 +1,114 @@
+package beyla
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"otel-checker/checks/utils"
+)
+
+const discoveryCheckID = "beyla.discovery"
+
+const discoveryDocURL = "https://grafana.com/docs/beyla/latest/configure/service-discovery/"
+
+// checkDiscovery checks that Beyla selects processes to instrument, and the
+// syntax of the ports, regular expressions and globs used to select them.
+func checkDiscovery(report *utils.Report, env utils.Env, c *Config) {
+	selectors := Selectors(env, c)
+	selected := false
+	for _, s := range selectors {
+		if s.Empty() {
+			report.Add(utils.Finding{
+				CheckID:     discoveryCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     s.Subject,
+				Location:    s.Location,
+				Message:     fmt.Sprintf("%s has no criteria, so it doesn't select any process", s.Subject),
+				Remediation: "Set open_ports, exe_path or k8s_ attributes in the entry, or remove it",
+				DocURL:      discoveryDocURL,
+			})
+			continue
+		}
+		if !s.Exclude {
+			selected = true
+		}
+		checkSelector(report, s)
+	}
+
+	if !selected {
+		report.Add(utils.Finding{
+			CheckID:     discoveryCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     "BEYLA_OPEN_PORT",
+			Message:     "BEYLA_OPEN_PORT must be set, or the processes to instrument selected another way, so Beyla knows which processes to instrument",
+			Remediation: `Run 'export BEYLA_OPEN_PORT=<port of your service>', set BEYLA_EXECUTABLE_NAME, or add entries to discovery.services in the config file`,
+			DocURL:      discoveryDocURL,
+		})
+	}
+}
+
+func checkSelector(report *utils.Report, s Selector) {
+	if ports := s.OpenPorts; ports.Value != "" {
+		if _, err := ParsePorts(ports.Value); err != nil {
+			report.Add(utils.Finding{
+				CheckID:     discoveryCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     ports.Subject,
+				Location:    ports.Location,
+				Message:     fmt.Sprintf("%s is not a valid port or port range: %s", ports.Subject, err),
+				Remediation: "Use a comma separated list of ports and port ranges, e.g. 80,443,8000-8999",
+				DocURL:      discoveryDocURL,
+			})
+		} else {
+			report.Add(utils.Finding{
+				CheckID:   discoveryCheckID,
+				Severity:  utils.CHECKS,
+				Component: "Beyla",
+				Subject:   ports.Subject,
+				Location:  ports.Location,
+				Message:   fmt.Sprintf("%s is set to a valid port or port range", ports.Subject),
+			})
+		}
+	}
+
+	patterns := s.Kubernetes
+	if s.ExePath.Value != "" {
+		patterns = append([]Criterion{s.ExePath}, patterns...)
+	}
+	for _, p := range patterns {
+		if p.Value == "" {
+			continue
+		}
+		if s.Glob {
+			if _, err := path.Match(p.Value, ""); err != nil {
+				report.Add(utils.Finding{
+					CheckID:     discoveryCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Beyla",
+					Subject:     p.Subject,
+					Location:    p.Location,
+					Message:     fmt.Sprintf("%s is not a valid glob: %s", p.Subject, err),
+					Remediation: "Check the brackets of the pattern, e.g. *server*",
+					DocURL:      discoveryDocURL,
+				})
+			}
+			continue
+		}
+		if _, err := regexp.Compile(p.Value); err != nil {
+			report.Add(utils.Finding{
+				CheckID:     discoveryCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     p.Subject,
+				Location:    p.Location,
+				Message:     fmt.Sprintf("%s is not a valid regular expression: %s", p.Subject, err),
+				Remediation: "Escape the special characters of the pattern, e.g. 'my-app\\.jar'",
+				DocURL:      discoveryDocURL,
+			})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package beyla
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckDiscovery(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		config     string
+		wantErrors []string
+		wantChecks []string
+	}{
+		{
+			name:       "open port",
+			env:        map[string]string{"BEYLA_OPEN_PORT": "8000-8999,443"},
+			wantChecks: []string{"BEYLA_OPEN_PORT"},
+		},
+		{
+			name:       "nothing selected",
+			env:        map[string]string{},
+			wantErrors: []string{"BEYLA_OPEN_PORT"},
+		},
+		{
+			name:       "invalid executable name",
+			env:        map[string]string{"BEYLA_EXECUTABLE_NAME": "my-app(.jar"},
+			wantErrors: []string{"BEYLA_EXECUTABLE_NAME"},
+		},
+		{
+			name: "discovery services",
+			config: `
+open_port: 8080
+discovery:
+  services:
+    - open_ports: 80,http
+    - exe_path: "[a-z+"
+      k8s_deployment_name: "(api"
+    - name: empty
+  instrument:
+    - exe_path: "*server*"
+    - exe_path: "[server"
+`,
+			wantErrors: []string{
+				"discovery.services[0].open_ports",
+				"discovery.services[1].exe_path",
+				"discovery.services[1].k8s_deployment_name",
+				"discovery.services[2]",
+				"discovery.instrument[1].exe_path",
+			},
+			wantChecks: []string{"open_port"},
+		},
+		{
+			name: "only exclusions",
+			config: `
+discovery:
+  exclude_services:
+    - exe_path: sidecar
+`,
+			wantErrors: []string{"BEYLA_OPEN_PORT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c *Config
+			if tt.config != "" {
+				var err error
+				if c, err = ParseConfig("beyla-config.yml", []byte(tt.config)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			report := utils.NewReport()
+			checkDiscovery(report, utils.Env{Getenv: utils.MapGetenv(tt.env)}, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.CHECKS); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package beyla
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const exportCheckID = "beyla.export"
+
+const exportDocURL = "https://grafana.com/docs/beyla/latest/configure/export-data/"
+
+var grafanaOtlpEndpoint = regexp.MustCompile(`^https://.+\.grafana\.net/otlp/?$`)
+
+var otlpProtocols = []string{"grpc", "http/protobuf", "http/json"}
+
+// Values of otel_metrics_export.features.
+var metricsFeatures = []string{"application", "application_span", "application_service_graph", "application_process", "application_host", "network", "network_inter_zone"}
+
+// checkExports checks the OTLP endpoints and protocols the traces and metrics
+// are exported with, when they are set in the config file c.
+func checkExports(report *utils.Report, c *Config) {
+	for _, section := range []string{"otel_traces_export", "otel_metrics_export"} {
+		if c.Get(section) == nil {
+			continue
+		}
+		protocol := c.GetString(section, "protocol")
+		if protocol != "" && !slices.Contains(otlpProtocols, protocol) {
+			report.Add(utils.Finding{
+				CheckID:     exportCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     section + ".protocol",
+				Location:    c.Location(c.Get(section, "protocol")),
+				Message:     fmt.Sprintf("%s.protocol is set to '%s', which is not an OTLP protocol", section, protocol),
+				Remediation: fmt.Sprintf("Set it to one of %s", strings.Join(otlpProtocols, ", ")),
+				DocURL:      exportDocURL,
+			})
+		}
+		signal := strings.TrimSuffix(strings.TrimPrefix(section, "otel_"), "_export")
+		for _, key := range []string{"endpoint", signal + "_endpoint"} {
+			if endpoint := c.GetString(section, key); endpoint != "" {
+				checkEndpoint(report, c, c.Get(section, key), section+"."+key, endpoint, protocol)
+			}
+		}
+	}
+
+	if interval := c.GetString("otel_metrics_export", "interval"); interval != "" {
+		if _, err := time.ParseDuration(interval); err != nil {
+			report.Add(utils.Finding{
+				CheckID:     exportCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     "otel_metrics_export.interval",
+				Location:    c.Location(c.Get("otel_metrics_export", "interval")),
+				Message:     fmt.Sprintf("otel_metrics_export.interval is set to '%s', which is not a duration", interval),
+				Remediation: "Use a duration with a unit, e.g. 30s",
+				DocURL:      exportDocURL,
+			})
+		}
+	}
+	if features := c.Get("otel_metrics_export", "features"); features != nil && features.Kind == yaml.SequenceNode {
+		for _, feature := range features.Content {
+			if !slices.Contains(metricsFeatures, feature.Value) {
+				report.Add(utils.Finding{
+					CheckID:     exportCheckID,
+					Severity:    utils.WARNINGS,
+					Component:   "Beyla",
+					Subject:     "otel_metrics_export.features",
+					Location:    c.Location(feature),
+					Message:     fmt.Sprintf("otel_metrics_export.features contains '%s', which is not a metrics feature of Beyla, so it is ignored", feature.Value),
+					Remediation: fmt.Sprintf("Use %s", strings.Join(metricsFeatures, ", ")),
+					DocURL:      exportDocURL,
+				})
+			}
+		}
+	}
+}
+
+func checkEndpoint(report *utils.Report, c *Config, node *yaml.Node, subject string, endpoint string, protocol string) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		report.Add(utils.Finding{
+			CheckID:     exportCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     subject,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("%s is set to '%s', which is not an http:// or https:// URL", subject, endpoint),
+			Remediation: "Set the URL of the OTLP endpoint, e.g. http://localhost:4318",
+			DocURL:      exportDocURL,
+		})
+		return
+	}
+	if !strings.HasSuffix(u.Hostname(), ".grafana.net") {
+		report.Add(utils.Finding{
+			CheckID:   exportCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Beyla",
+			Subject:   subject,
+			Location:  c.Location(node),
+			Message:   fmt.Sprintf("%s is a valid URL", subject),
+		})
+		return
+	}
+
+	if !grafanaOtlpEndpoint.MatchString(endpoint) {
+		report.Add(utils.Finding{
+			CheckID:     exportCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     subject,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("%s is not set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp", subject),
+			Remediation: "Copy the OTLP endpoint from the OpenTelemetry section of your Grafana Cloud stack",
+			DocURL:      exportDocURL,
+		})
+		return
+	}
+	if protocol == "grpc" {
+		report.Add(utils.Finding{
+			CheckID:     exportCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     subject,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("%s is a Grafana Cloud OTLP endpoint, which doesn't support gRPC", subject),
+			Remediation: "Set the protocol to http/protobuf",
+			DocURL:      exportDocURL,
+		})
+		return
+	}
+	report.Add(utils.Finding{
+		CheckID:   exportCheckID,
+		Severity:  utils.CHECKS,
+		Component: "Beyla",
+		Subject:   subject,
+		Location:  c.Location(node),
+		Message:   fmt.Sprintf("%s is set to a Grafana Cloud OTLP endpoint", subject),
+	})
+}
@@ -0,0 +1,83 @@
+package beyla
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckExports(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantErrors   []string
+		wantWarnings []string
+		wantLines    []int
+	}{
+		{
+			name: "valid",
+			config: `
+otel_traces_export:
+  endpoint: https://otlp-gateway-prod-us-east-0.grafana.net/otlp
+otel_metrics_export:
+  endpoint: http://localhost:4318
+  protocol: http/protobuf
+  interval: 30s
+  features: [application, network]
+`,
+		},
+		{
+			name: "invalid endpoints",
+			config: `
+otel_traces_export:
+  endpoint: localhost:4317
+  traces_endpoint: https://otlp-gateway-prod-us-east-0.grafana.net/v1/traces
+otel_metrics_export:
+  endpoint: https://otlp-gateway-prod-us-east-0.grafana.net/otlp
+  protocol: grpc
+`,
+			wantErrors: []string{"otel_traces_export.endpoint", "otel_traces_export.traces_endpoint", "otel_metrics_export.endpoint"},
+			wantLines:  []int{3, 4, 6},
+		},
+		{
+			name: "invalid settings",
+			config: `
+otel_metrics_export:
+  protocol: http
+  interval: 30
+  features:
+    - application
+    - applications
+`,
+			wantErrors:   []string{"otel_metrics_export.protocol", "otel_metrics_export.interval"},
+			wantWarnings: []string{"otel_metrics_export.features"},
+			wantLines:    []int{3, 4, 7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("beyla-config.yml", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			checkExports(report, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			var lines []int
+			for _, f := range append(report.BySeverity(utils.ERRORS), report.BySeverity(utils.WARNINGS)...) {
+				lines = append(lines, f.Location.Line)
+			}
+			if !slices.Equal(lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
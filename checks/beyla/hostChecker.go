@@ -0,0 +1,234 @@
+package beyla
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+const hostCheckID = "beyla.host"
+
+const setupDocURL = "https://grafana.com/docs/beyla/latest/setup/"
+const securityDocURL = "https://grafana.com/docs/beyla/latest/security/"
+
+// Oldest kernel supported by Beyla, and the one of Red Hat Enterprise Linux,
+// which has the eBPF features of newer kernels backported.
+const minKernel = "5.8"
+const minRHELKernel = "4.18"
+
+// Kernels older than this one account the eBPF maps in the locked memory.
+const memlockKernel = "5.11"
+
+var kernelVersion = regexp.MustCompile(`^Linux version (\d+\.\d+(?:\.\d+)?)(\S*)`)
+
+var rhelRelease = regexp.MustCompile(`\.el\d+`)
+
+// capability is a Linux capability, with its bit in the capability sets of
+// /proc/<pid>/status and what Beyla needs it for.
+type capability struct {
+	name   string
+	bit    uint
+	reason string
+}
+
+var (
+	capDacReadSearch     = capability{"CAP_DAC_READ_SEARCH", 2, "to read the executables of the instrumented processes"}
+	capNetRaw            = capability{"CAP_NET_RAW", 13, "to capture the network flows for the network metrics"}
+	capSysPtrace         = capability{"CAP_SYS_PTRACE", 19, "to access the namespaces and memory of the instrumented processes"}
+	capSysAdmin          = capability{"CAP_SYS_ADMIN", 21, ""}
+	capSysResource       = capability{"CAP_SYS_RESOURCE", 24, "to increase the locked memory for its eBPF maps on kernels older than " + memlockKernel}
+	capPerfmon           = capability{"CAP_PERFMON", 38, "to attach its eBPF probes"}
+	capBPF               = capability{"CAP_BPF", 39, "to load its eBPF programs"}
+	capCheckpointRestore = capability{"CAP_CHECKPOINT_RESTORE", 40, "to find the executables of the instrumented processes"}
+)
+
+// Capabilities that CAP_SYS_ADMIN grants too, for compatibility with the
+// kernels from before they were split from it.
+var sysAdminCapabilities = []capability{capPerfmon, capBPF, capCheckpointRestore}
+
+// checkHost checks the prerequisites of Beyla on the host whose /proc and /sys
+// are under root: the kernel version, BTF and the capabilities of the current
+// process, which are the ones Beyla gets when it is run the same way.
+func checkHost(report *utils.Report, env utils.Env, root string, networkMetrics bool) {
+	kernel := checkKernel(report, env, root)
+	checkBTF(report, env, root)
+	checkCapabilities(report, env, root, kernel, networkMetrics)
+}
+
+// checkKernel checks the version of the kernel, and returns it, or "" when it
+// can't be read.
+func checkKernel(report *utils.Report, env utils.Env, root string) string {
+	file := path.Join(root, "proc/version")
+	data, err := env.ReadFile(file)
+	if err != nil {
+		report.Add(utils.Finding{
+			CheckID:     hostCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     "kernel",
+			Message:     fmt.Sprintf("Could not read the kernel version from %s: %s. Beyla only runs on Linux", file, err),
+			Remediation: "Run Beyla on a Linux host, or in a Linux VM or container",
+			DocURL:      setupDocURL,
+		})
+		return ""
+	}
+	m := kernelVersion.FindStringSubmatch(string(data))
+	if m == nil {
+		report.Add(utils.Finding{
+			CheckID:   hostCheckID,
+			Severity:  utils.WARNINGS,
+			Component: "Beyla",
+			Subject:   "kernel",
+			Message:   fmt.Sprintf("Could not find the kernel version in %s, so it is not checked. Beyla requires Linux %s or later", file, minKernel),
+			DocURL:    setupDocURL,
+		})
+		return ""
+	}
+
+	version, release := m[1], m[1]+m[2]
+	switch {
+	case utils.CompareVersions(version, minKernel) >= 0:
+		report.Add(utils.Finding{
+			CheckID:   hostCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Beyla",
+			Subject:   "kernel",
+			Message:   fmt.Sprintf("Linux kernel %s is supported by Beyla", release),
+		})
+	case rhelRelease.MatchString(release) && utils.CompareVersions(version, minRHELKernel) >= 0:
+		report.Add(utils.Finding{
+			CheckID:   hostCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Beyla",
+			Subject:   "kernel",
+			Message:   fmt.Sprintf("Linux kernel %s of Red Hat Enterprise Linux is supported by Beyla", release),
+		})
+	default:
+		report.Add(utils.Finding{
+			CheckID:     hostCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     "kernel",
+			Message:     fmt.Sprintf("Linux kernel %s is too old for Beyla, which requires %s or later, or %s on Red Hat Enterprise Linux", release, minKernel, minRHELKernel),
+			Remediation: fmt.Sprintf("Upgrade the kernel of the host to %s or later", minKernel),
+			DocURL:      setupDocURL,
+		})
+	}
+	return version
+}
+
+func checkBTF(report *utils.Report, env utils.Env, root string) {
+	file := path.Join(root, "sys/kernel/btf/vmlinux")
+	if _, err := env.Stat(file); err != nil {
+		report.Add(utils.Finding{
+			CheckID:     hostCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     "BTF",
+			Message:     fmt.Sprintf("BTF is not available at %s, and Beyla needs it to run its eBPF programs on this kernel", file),
+			Remediation: "Use a kernel built with CONFIG_DEBUG_INFO_BTF=y, which is the default of most distributions, or mount /sys/kernel/btf in the container of Beyla",
+			DocURL:      setupDocURL,
+		})
+		return
+	}
+	report.Add(utils.Finding{
+		CheckID:   hostCheckID,
+		Severity:  utils.CHECKS,
+		Component: "Beyla",
+		Subject:   "BTF",
+		Message:   fmt.Sprintf("BTF is available at %s", file),
+	})
+}
+
+// checkCapabilities checks the effective capabilities of the current process
+// against the ones Beyla needs on the kernel version, when it is known.
+func checkCapabilities(report *utils.Report, env utils.Env, root string, kernel string, networkMetrics bool) {
+	file := path.Join(root, "proc/self/status")
+	effective, err := effectiveCapabilities(env, file)
+	if err != nil {
+		report.Add(utils.Finding{
+			CheckID:   hostCheckID,
+			Severity:  utils.WARNINGS,
+			Component: "Beyla",
+			Subject:   "capabilities",
+			Message:   fmt.Sprintf("Could not read the capabilities of the process from %s, so they are not checked: %s", file, err),
+			DocURL:    securityDocURL,
+		})
+		return
+	}
+
+	required := []capability{capBPF, capPerfmon, capDacReadSearch, capSysPtrace, capCheckpointRestore}
+	if kernel != "" && utils.CompareVersions(kernel, memlockKernel) < 0 {
+		required = append(required, capSysResource)
+	}
+	if networkMetrics {
+		required = append(required, capNetRaw)
+	}
+
+	has := func(c capability) bool {
+		return effective&(1<<c.bit) != 0
+	}
+	for _, c := range required {
+		switch {
+		case has(c):
+			report.Add(utils.Finding{
+				CheckID:   hostCheckID,
+				Severity:  utils.CHECKS,
+				Component: "Beyla",
+				Subject:   c.name,
+				Message:   fmt.Sprintf("The process has %s, which Beyla needs %s", c.name, c.reason),
+			})
+		case has(capSysAdmin) && slices.Contains(sysAdminCapabilities, c):
+			report.Add(utils.Finding{
+				CheckID:   hostCheckID,
+				Severity:  utils.CHECKS,
+				Component: "Beyla",
+				Subject:   c.name,
+				Message:   fmt.Sprintf("The process has %s, which grants %s", capSysAdmin.name, c.name),
+			})
+		default:
+			report.Add(utils.Finding{
+				CheckID:     hostCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Beyla",
+				Subject:     c.name,
+				Message:     fmt.Sprintf("The process doesn't have %s, which Beyla needs %s", c.name, c.reason),
+				Remediation: fmt.Sprintf("Run Beyla as root or privileged, or add %s to its capabilities, e.g. in securityContext.capabilities.add of its container", strings.TrimPrefix(c.name, "CAP_")),
+				DocURL:      securityDocURL,
+			})
+		}
+	}
+}
+
+// effectiveCapabilities returns the CapEff bitmask of a /proc/<pid>/status file.
+func effectiveCapabilities(env utils.Env, file string) (uint64, error) {
+	data, err := env.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		}
+	}
+	return 0, fmt.Errorf("no CapEff line")
+}
+
+// networkMetrics returns whether Beyla is configured to produce the network
+// metrics, by environment variables or in the config file c, which can be nil.
+func networkMetrics(env utils.Env, c *Config) bool {
+	if enabled := setting(env, c, "BEYLA_NETWORK_METRICS", "network", "enable"); enabled.Value == "true" {
+		return true
+	}
+	for _, feature := range strings.Split(setting(env, c, "BEYLA_OTEL_METRICS_FEATURES", "otel_metrics_export", "features").Value, ",") {
+		if strings.TrimSpace(feature) == "network" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package beyla
+
+import (
+	"path"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckHost(t *testing.T) {
+	const ubuntu = "Linux version 6.5.0-1015-azure (buildd@lcy02-amd64-055) (x86_64-linux-gnu-gcc-12) #15~22.04.1-Ubuntu SMP\n"
+	// CAP_DAC_READ_SEARCH, CAP_SYS_PTRACE, CAP_PERFMON, CAP_BPF and CAP_CHECKPOINT_RESTORE.
+	const required = "000001c000080004"
+
+	tests := []struct {
+		name         string
+		root         string
+		version      string
+		capEff       string
+		noBTF        bool
+		env          map[string]string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{name: "root", root: "/", version: ubuntu, capEff: "000001ffffffffff"},
+		{name: "host mounted in a container", root: "/host", version: ubuntu, capEff: required},
+		{
+			name:       "old kernel",
+			root:       "/",
+			version:    "Linux version 4.15.0-213-generic (buildd@lcy02-amd64-079) (gcc version 7.5.0)\n",
+			capEff:     required,
+			wantErrors: []string{"kernel", "CAP_SYS_RESOURCE"},
+		},
+		{
+			name:    "Red Hat Enterprise Linux",
+			root:    "/",
+			version: "Linux version 4.18.0-513.5.1.el8_9.x86_64 (mockbuild@x86-vm-08.build.eng.bos.redhat.com)\n",
+			capEff:  "000001ffffffffff",
+		},
+		{name: "missing BTF", root: "/", version: ubuntu, capEff: required, noBTF: true, wantErrors: []string{"BTF"}},
+		{name: "not Linux", root: "/", noBTF: true, wantErrors: []string{"kernel", "BTF"}, wantWarnings: []string{"capabilities"}},
+		{name: "unknown kernel version", root: "/", version: "Linux\n", capEff: required, wantWarnings: []string{"kernel"}},
+		{
+			name:       "default capabilities of docker",
+			root:       "/",
+			version:    ubuntu,
+			capEff:     "00000000a80425fb",
+			wantErrors: []string{"CAP_BPF", "CAP_PERFMON", "CAP_DAC_READ_SEARCH", "CAP_SYS_PTRACE", "CAP_CHECKPOINT_RESTORE"},
+		},
+		{
+			name:    "CAP_SYS_ADMIN instead of CAP_BPF, CAP_PERFMON and CAP_CHECKPOINT_RESTORE",
+			root:    "/",
+			version: ubuntu,
+			capEff:  "0000000000280004",
+		},
+		{
+			name:       "network metrics",
+			root:       "/",
+			version:    ubuntu,
+			capEff:     required,
+			env:        map[string]string{"BEYLA_NETWORK_METRICS": "true"},
+			wantErrors: []string{"CAP_NET_RAW"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := fstest.MapFS{}
+			if tt.version != "" {
+				files[path.Join(tt.root, "proc/version")[1:]] = &fstest.MapFile{Data: []byte(tt.version)}
+			}
+			if tt.capEff != "" {
+				files[path.Join(tt.root, "proc/self/status")[1:]] = &fstest.MapFile{Data: []byte("Name:\tbeyla\nCapPrm:\t" + tt.capEff + "\nCapEff:\t" + tt.capEff + "\n")}
+			}
+			if !tt.noBTF {
+				files[path.Join(tt.root, "sys/kernel/btf/vmlinux")[1:]] = &fstest.MapFile{Data: []byte("BTF")}
+			}
+			env := utils.Env{Getenv: utils.MapGetenv(tt.env), FS: files}
+			report := utils.NewReport()
+			checkHost(report, env, tt.root, networkMetrics(env, nil))
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestNetworkMetrics(t *testing.T) {
+	c, err := ParseConfig("beyla-config.yml", []byte("otel_metrics_export:\n  features: [application, network]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := utils.Env{Getenv: utils.MapGetenv(nil)}
+	if !networkMetrics(env, c) {
+		t.Error("network metrics enabled by otel_metrics_export.features are not detected")
+	}
+	if networkMetrics(env, nil) {
+		t.Error("network metrics detected without settings")
+	}
+}
@@ -0,0 +1,379 @@
+package beyla
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+const processesCheckID = "beyla.processes"
+
+// State of the listening sockets in /proc/<pid>/net/tcp.
+const tcpListen = "0A"
+
+// Number of processes listed in a finding, and above which a selector matches
+// more processes than it is expected to.
+const maxListed = 10
+const maxMatches = 20
+
+// Process is a process running on the host, as seen by the discovery of Beyla.
+type Process struct {
+	PID int
+	Exe string
+	// Ports are the TCP ports the process listens on, in increasing order.
+	Ports []int
+}
+
+func (p Process) String() string {
+	s := fmt.Sprintf("%s (pid %d", p.Exe, p.PID)
+	if len(p.Ports) > 0 {
+		var ports []string
+		for _, port := range p.Ports {
+			ports = append(ports, strconv.Itoa(port))
+		}
+		s += ", port " + strings.Join(ports, ", ")
+	}
+	return s + ")"
+}
+
+// Processes returns the processes running on the host whose /proc is under
+// root, except the current one, and the number of processes that could not be
+// inspected because the current process doesn't have the permissions to.
+func Processes(env utils.Env, root string) ([]Process, int, error) {
+	procDir := path.Join(root, "proc")
+	entries, err := env.ReadDir(procDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	self := -1
+	if link, err := env.Readlink(path.Join(procDir, "self")); err == nil {
+		self, _ = strconv.Atoi(path.Base(link))
+	}
+
+	var processes []Process
+	denied := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() || pid == self {
+			continue
+		}
+		dir := path.Join(procDir, entry.Name())
+		exe, err := env.Readlink(path.Join(dir, "exe"))
+		if err != nil {
+			// Kernel threads have no executable, and processes can exit while they are listed.
+			if errors.Is(err, fs.ErrPermission) {
+				denied++
+			}
+			continue
+		}
+		ports, err := listeningPorts(env, dir)
+		if errors.Is(err, fs.ErrPermission) {
+			denied++
+			continue
+		}
+		processes = append(processes, Process{PID: pid, Exe: exe, Ports: ports})
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PID < processes[j].PID
+	})
+	return processes, denied, nil
+}
+
+// listeningPorts returns the TCP ports a process listens on: the ones of the
+// listening sockets of /proc/<pid>/net/tcp and tcp6, which list the sockets of
+// its network namespace, that are among its file descriptors.
+func listeningPorts(env utils.Env, dir string) ([]int, error) {
+	fds, err := env.ReadDir(path.Join(dir, "fd"))
+	if err != nil {
+		return nil, err
+	}
+	inodes := map[string]bool{}
+	for _, fd := range fds {
+		link, err := env.Readlink(path.Join(dir, "fd", fd.Name()))
+		if inode, ok := strings.CutPrefix(link, "socket:["); err == nil && ok {
+			inodes[strings.TrimSuffix(inode, "]")] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	var ports []int
+	for _, file := range []string{"net/tcp", "net/tcp6"} {
+		data, err := env.ReadFile(path.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		// The lines are in the format "sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...".
+		for _, line := range strings.Split(string(data), "\n")[1:] {
+			fields := strings.Fields(line)
+			if len(fields) < 10 || fields[3] != tcpListen || !inodes[fields[9]] {
+				continue
+			}
+			_, hexPort, _ := strings.Cut(fields[1], ":")
+			if port, err := strconv.ParseUint(hexPort, 16, 16); err == nil && !slices.Contains(ports, int(port)) {
+				ports = append(ports, int(port))
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// matcher is a selector with its criteria parsed.
+type matcher struct {
+	Selector
+	ports []PortRange
+	exe   *regexp.Regexp
+}
+
+func newMatcher(s Selector) (*matcher, error) {
+	m := &matcher{Selector: s}
+	var err error
+	if s.OpenPorts.Value != "" {
+		if m.ports, err = ParsePorts(s.OpenPorts.Value); err != nil {
+			return nil, err
+		}
+	}
+	if s.ExePath.Value != "" {
+		if s.Glob {
+			m.exe, err = globRegexp(s.ExePath.Value)
+		} else {
+			m.exe, err = regexp.Compile(s.ExePath.Value)
+		}
+	}
+	return m, err
+}
+
+// matches returns whether p matches the criteria of the selector that can be
+// checked from /proc, which doesn't have the Kubernetes metadata.
+func (m *matcher) matches(p Process) bool {
+	if m.exe != nil && !m.exe.MatchString(p.Exe) {
+		return false
+	}
+	if m.ports == nil {
+		return true
+	}
+	for _, port := range p.Ports {
+		for _, r := range m.ports {
+			if r.Contains(port) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// criteria returns the criteria of the selector, such as "BEYLA_OPEN_PORT=8080".
+func (m *matcher) criteria() string {
+	var criteria []string
+	for _, c := range append([]Criterion{m.OpenPorts, m.ExePath}, m.Kubernetes...) {
+		if c.Value == "" {
+			continue
+		}
+		name := c.Subject
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		criteria = append(criteria, name+"="+c.Value)
+	}
+	return strings.Join(criteria, ", ")
+}
+
+// globRegexp converts a glob of the instrument sections, which can contain *,
+// ?, [...] and {a,b}, into a regular expression matching the whole path.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*':
+			sb.WriteString(".*")
+		case c == '?':
+			sb.WriteString(".")
+		case c == '{':
+			braces++
+			sb.WriteString("(?:")
+		case c == '}' && braces > 0:
+			braces--
+			sb.WriteString(")")
+		case c == ',' && braces > 0:
+			sb.WriteString("|")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in %q", glob)
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// checkProcesses lists the running processes that Beyla would instrument with
+// the discovery criteria, when Beyla runs on the host whose /proc is under root.
+func checkProcesses(report *utils.Report, env utils.Env, c *Config, root string) {
+	var include, exclude []*matcher
+	for _, s := range Selectors(env, c) {
+		m, err := newMatcher(s)
+		if err != nil || s.Empty() {
+			// Reported by checkDiscovery.
+			continue
+		}
+		if m.ports == nil && m.exe == nil {
+			// Selects by Kubernetes metadata only, which is not in /proc.
+			continue
+		}
+		if s.Exclude {
+			exclude = append(exclude, m)
+		} else {
+			include = append(include, m)
+		}
+	}
+	if len(include) == 0 {
+		// Reported by checkDiscovery.
+		return
+	}
+
+	processes, denied, err := Processes(env, root)
+	if err != nil {
+		report.Add(utils.Finding{
+			CheckID:   processesCheckID,
+			Severity:  utils.WARNINGS,
+			Component: "Beyla",
+			Subject:   "processes",
+			Message:   fmt.Sprintf("Could not list the running processes from %s, so the processes Beyla would instrument are not checked: %s", path.Join(root, "proc"), err),
+			DocURL:    discoveryDocURL,
+		})
+		return
+	}
+	if denied > 0 {
+		report.Add(utils.Finding{
+			CheckID:     processesCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Beyla",
+			Subject:     "processes",
+			Message:     fmt.Sprintf("%d running processes could not be inspected, so they are not checked against the discovery criteria", denied),
+			Remediation: "Run otel-checker as root, or with the same capabilities as Beyla",
+			DocURL:      discoveryDocURL,
+		})
+	}
+
+	for _, m := range include {
+		var matched []Process
+		for _, p := range processes {
+			if m.matches(p) && !slices.ContainsFunc(exclude, func(e *matcher) bool { return e.matches(p) }) {
+				matched = append(matched, p)
+			}
+		}
+		checkMatches(report, m, matched, processes)
+	}
+}
+
+func checkMatches(report *utils.Report, m *matcher, matched []Process, processes []Process) {
+	location := m.Location
+	if location == nil {
+		location = m.OpenPorts.Location
+	}
+	if location == nil {
+		location = m.ExePath.Location
+	}
+	var executables []string
+	for _, p := range matched {
+		if !slices.Contains(executables, p.Exe) {
+			executables = append(executables, p.Exe)
+		}
+	}
+
+	switch {
+	case len(matched) == 0:
+		var listening []Process
+		for _, p := range processes {
+			if len(p.Ports) > 0 {
+				listening = append(listening, p)
+			}
+		}
+		remediation := "Start your application before running otel-checker, and check the port it listens on and the path of its executable"
+		if len(listening) > 0 {
+			remediation = fmt.Sprintf("Check the criteria against the processes listening on TCP ports: %s", processList(listening))
+		}
+		message := fmt.Sprintf("No running process matches %s (%s), so Beyla would not instrument any process for it", m.Subject, m.criteria())
+		if len(m.Kubernetes) > 0 {
+			message += ". The Kubernetes attributes were not checked"
+		}
+		report.Add(utils.Finding{
+			CheckID:     processesCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Beyla",
+			Subject:     m.Subject,
+			Location:    location,
+			Message:     message,
+			Remediation: remediation,
+			DocURL:      discoveryDocURL,
+		})
+	case m.Name != "" && len(executables) > 1:
+		report.Add(utils.Finding{
+			CheckID:     processesCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Beyla",
+			Subject:     m.Subject,
+			Location:    location,
+			Message:     fmt.Sprintf("%s (%s) matches processes of %d executables, which are all reported as the service %s: %s", m.Subject, m.criteria(), len(executables), m.Name, processList(matched)),
+			Remediation: "Make the criteria match only the processes of the service, e.g. with the path of its executable, or add a discovery.services entry per service",
+			DocURL:      discoveryDocURL,
+		})
+	case len(matched) > maxMatches:
+		report.Add(utils.Finding{
+			CheckID:     processesCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Beyla",
+			Subject:     m.Subject,
+			Location:    location,
+			Message:     fmt.Sprintf("%s (%s) matches %d processes, more than the %d expected: %s", m.Subject, m.criteria(), len(matched), maxMatches, processList(matched)),
+			Remediation: "Make the criteria match only the processes of your services, e.g. with the path of their executables",
+			DocURL:      discoveryDocURL,
+		})
+	default:
+		report.Add(utils.Finding{
+			CheckID:   processesCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Beyla",
+			Subject:   m.Subject,
+			Location:  location,
+			Message:   fmt.Sprintf("%s (%s) matches %s", m.Subject, m.criteria(), processList(matched)),
+		})
+	}
+}
+
+// processList returns the first processes of processes, separated by ",".
+func processList(processes []Process) string {
+	var list []string
+	for i, p := range processes {
+		if i == maxListed {
+			list = append(list, fmt.Sprintf("and %d more", len(processes)-maxListed))
+			break
+		}
+		list = append(list, p.String())
+	}
+	return strings.Join(list, ", ")
+}
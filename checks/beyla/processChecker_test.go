@@ -0,0 +1,228 @@
+package beyla
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+type fixtureProcess struct {
+	pid    int
+	exe    string
+	listen []int
+	// connected are the local ports of connected sockets, which are not listening.
+	connected []int
+}
+
+// procFS returns a /proc under root with processes, which are all in the same
+// network namespace, so their net/tcp files list the sockets of all of them.
+func procFS(root string, processes ...fixtureProcess) fstest.MapFS {
+	files := fstest.MapFS{}
+	var tcp, tcp6 strings.Builder
+	tcp.WriteString(tcpHeader)
+	tcp6.WriteString(tcpHeader)
+	for _, p := range processes {
+		dir := path.Join(root, "proc", fmt.Sprint(p.pid))[1:]
+		files[dir+"/cmdline"] = &fstest.MapFile{Data: []byte(p.exe)}
+		if p.exe != "" {
+			files[dir+"/exe"] = &fstest.MapFile{Data: []byte(p.exe), Mode: fs.ModeSymlink}
+		}
+		files[dir+"/fd/0"] = &fstest.MapFile{Data: []byte("/dev/null"), Mode: fs.ModeSymlink}
+		for i, port := range append(p.listen, p.connected...) {
+			inode := p.pid*100 + i
+			files[fmt.Sprintf("%s/fd/%d", dir, i+3)] = &fstest.MapFile{Data: []byte(fmt.Sprintf("socket:[%d]", inode)), Mode: fs.ModeSymlink}
+			state := tcpListen
+			if i >= len(p.listen) {
+				state = "01"
+			}
+			// The sockets with an odd port are IPv6 ones.
+			table, address := &tcp, "0100007F"
+			if port%2 == 1 {
+				table, address = &tcp6, "00000000000000000000000000000000"
+			}
+			fmt.Fprintf(table, "%4d: %s:%04X 00000000:0000 %s 00000000:00000000 00:00000000 00000000  1000        0 %d 1 0000000000000000 100 0 0 10 0\n", i, address, port, state, inode)
+		}
+	}
+	for _, p := range processes {
+		dir := path.Join(root, "proc", fmt.Sprint(p.pid))[1:]
+		files[dir+"/net/tcp"] = &fstest.MapFile{Data: []byte(tcp.String())}
+		files[dir+"/net/tcp6"] = &fstest.MapFile{Data: []byte(tcp6.String())}
+	}
+	return files
+}
+
+func TestProcesses(t *testing.T) {
+	files := procFS("/",
+		fixtureProcess{pid: 1, exe: "/sbin/init"},
+		// A kernel thread, without executable.
+		fixtureProcess{pid: 2},
+		fixtureProcess{pid: 310, exe: "/usr/bin/java", listen: []int{8080, 8443}, connected: []int{40000}},
+		fixtureProcess{pid: 42, exe: "/usr/local/bin/node", listen: []int{3001}},
+		fixtureProcess{pid: 500, exe: "/usr/local/bin/otel-checker", listen: []int{9090}},
+	)
+	files["proc/self"] = &fstest.MapFile{Data: []byte("500"), Mode: fs.ModeSymlink}
+
+	processes, denied, err := Processes(utils.Env{FS: files}, "/")
+	if err != nil || denied != 0 {
+		t.Fatalf("Processes() = %v, %d, %v", processes, denied, err)
+	}
+	var got []string
+	for _, p := range processes {
+		got = append(got, p.String())
+	}
+	want := []string{"/sbin/init (pid 1)", "/usr/local/bin/node (pid 42, port 3001)", "/usr/bin/java (pid 310, port 8080, 8443)"}
+	if !slices.Equal(got, want) {
+		t.Errorf("processes = %q, want %q", got, want)
+	}
+
+	if _, _, err := Processes(utils.Env{FS: fstest.MapFS{}}, "/"); err == nil {
+		t.Error("expected an error without /proc")
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		glob    string
+		matches []string
+		others  []string
+	}{
+		{glob: "*server*", matches: []string{"/usr/bin/server", "/opt/my-server-1"}, others: []string{"/usr/bin/java"}},
+		{glob: "/usr/bin/{java,node}", matches: []string{"/usr/bin/java", "/usr/bin/node"}, others: []string{"/usr/bin/javac"}},
+		{glob: "/app/v?/[!x]*", matches: []string{"/app/v1/api"}, others: []string{"/app/v10/api", "/app/v1/xapi"}},
+		{glob: "/app/main.py", matches: []string{"/app/main.py"}, others: []string{"/app/mainXpy"}},
+	}
+	for _, tt := range tests {
+		re, err := globRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("globRegexp(%q) error: %v", tt.glob, err)
+		}
+		for _, s := range tt.matches {
+			if !re.MatchString(s) {
+				t.Errorf("%q does not match %q", tt.glob, s)
+			}
+		}
+		for _, s := range tt.others {
+			if re.MatchString(s) {
+				t.Errorf("%q matches %q", tt.glob, s)
+			}
+		}
+	}
+	if _, err := globRegexp("[server"); err == nil {
+		t.Error("expected an error for an unclosed [")
+	}
+}
+
+func TestCheckProcesses(t *testing.T) {
+	processes := []fixtureProcess{
+		{pid: 10, exe: "/usr/bin/java", listen: []int{8080}},
+		{pid: 11, exe: "/usr/bin/java", listen: []int{8081}},
+		{pid: 20, exe: "/usr/local/bin/node", listen: []int{3000}, connected: []int{8080}},
+		{pid: 30, exe: "/usr/local/bin/envoy", listen: []int{8443}},
+		{pid: 40, exe: "/usr/bin/bash"},
+	}
+
+	tests := []struct {
+		name         string
+		root         string
+		noProc       bool
+		env          map[string]string
+		config       string
+		wantWarnings []string
+		wantChecks   []string
+		wantMessage  string
+	}{
+		{
+			name:        "open port",
+			root:        "/",
+			env:         map[string]string{"BEYLA_OPEN_PORT": "8080"},
+			wantChecks:  []string{"BEYLA_OPEN_PORT"},
+			wantMessage: "BEYLA_OPEN_PORT (BEYLA_OPEN_PORT=8080) matches /usr/bin/java (pid 10, port 8080)",
+		},
+		{
+			name:        "host mounted in a container",
+			root:        "/host",
+			env:         map[string]string{"BEYLA_OPEN_PORT": "8000-8999", "BEYLA_EXECUTABLE_NAME": "java$"},
+			wantChecks:  []string{"BEYLA_OPEN_PORT and BEYLA_EXECUTABLE_NAME"},
+			wantMessage: "/usr/bin/java (pid 10, port 8080), /usr/bin/java (pid 11, port 8081)",
+		},
+		{
+			name:         "nothing matches",
+			root:         "/",
+			env:          map[string]string{"BEYLA_OPEN_PORT": "9090"},
+			wantWarnings: []string{"BEYLA_OPEN_PORT"},
+			wantMessage:  "/usr/bin/java (pid 10, port 8080), /usr/bin/java (pid 11, port 8081), /usr/local/bin/node (pid 20, port 3000), /usr/local/bin/envoy (pid 30, port 8443)",
+		},
+		{
+			name:         "service name on several executables",
+			root:         "/",
+			env:          map[string]string{"BEYLA_OPEN_PORT": "3000,8443", "BEYLA_SERVICE_NAME": "shop"},
+			wantWarnings: []string{"BEYLA_OPEN_PORT"},
+			wantMessage:  "matches processes of 2 executables, which are all reported as the service shop",
+		},
+		{
+			name: "discovery services",
+			root: "/",
+			config: `
+discovery:
+  services:
+    - exe_path: java
+    - open_ports: 443
+      k8s_namespace: prod
+    - k8s_deployment_name: api
+  exclude_services:
+    - open_ports: 8081
+  instrument:
+    - exe_path: "*/{node,envoy}"
+`,
+			wantWarnings: []string{"discovery.services[1]"},
+			wantChecks:   []string{"discovery.services[0]", "discovery.instrument[0]"},
+			wantMessage:  "The Kubernetes attributes were not checked",
+		},
+		{
+			name:         "no /proc",
+			root:         "/",
+			noProc:       true,
+			env:          map[string]string{"BEYLA_OPEN_PORT": "8080"},
+			wantWarnings: []string{"processes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c *Config
+			if tt.config != "" {
+				var err error
+				if c, err = ParseConfig("beyla-config.yml", []byte(tt.config)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			files := procFS(tt.root, processes...)
+			if tt.noProc {
+				files = fstest.MapFS{}
+			}
+			env := utils.Env{Getenv: utils.MapGetenv(tt.env), FS: files}
+			report := utils.NewReport()
+			checkProcesses(report, env, c, tt.root)
+
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			if got := utilstest.Subjects(report, utils.CHECKS); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+			found := tt.wantMessage == ""
+			for _, f := range report.Findings {
+				found = found || strings.Contains(f.Message, tt.wantMessage) || strings.Contains(f.Remediation, tt.wantMessage)
+			}
+			if !found {
+				t.Errorf("no finding contains %q: %v", tt.wantMessage, report.Findings)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package beyla
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const routesCheckID = "beyla.routes"
+
+const routesDocURL = "https://grafana.com/docs/beyla/latest/configure/routes-decorator/"
+
+var unmatchedModes = []string{"heuristic", "path", "wildcard", "unset"}
+
+var ignoreModes = []string{"traces", "metrics"}
+
+// checkRoutes checks the routes section of the config file c, which sets how
+// the routes of HTTP requests are reported.
+func checkRoutes(report *utils.Report, c *Config) {
+	if c.Get("routes") == nil {
+		return
+	}
+
+	switch unmatched := c.GetString("routes", "unmatched"); {
+	case unmatched == "path":
+		report.Add(utils.Finding{
+			CheckID:     routesCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Beyla",
+			Subject:     "routes.unmatched",
+			Location:    c.Location(c.Get("routes", "unmatched")),
+			Message:     "routes.unmatched is set to 'path', so every URL path not matching routes.patterns is reported as a route, which can create many metric series",
+			Remediation: "Set routes.unmatched to 'heuristic', or add the routes of your service to routes.patterns",
+			DocURL:      routesDocURL,
+		})
+	case unmatched != "" && !slices.Contains(unmatchedModes, unmatched):
+		report.Add(utils.Finding{
+			CheckID:     routesCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     "routes.unmatched",
+			Location:    c.Location(c.Get("routes", "unmatched")),
+			Message:     fmt.Sprintf("routes.unmatched is set to '%s', which is not a valid mode", unmatched),
+			Remediation: fmt.Sprintf("Set it to one of %s", strings.Join(unmatchedModes, ", ")),
+			DocURL:      routesDocURL,
+		})
+	}
+
+	for _, key := range []string{"patterns", "ignored_patterns"} {
+		n := c.Get("routes", key)
+		if n == nil || isNull(n) {
+			continue
+		}
+		if n.Kind != yaml.SequenceNode {
+			report.Add(utils.Finding{
+				CheckID:   routesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Beyla",
+				Subject:   "routes." + key,
+				Location:  c.Location(n),
+				Message:   fmt.Sprintf("routes.%s must be a list of routes", key),
+				DocURL:    routesDocURL,
+			})
+			continue
+		}
+		for _, pattern := range n.Content {
+			if !strings.HasPrefix(pattern.Value, "/") {
+				report.Add(utils.Finding{
+					CheckID:     routesCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Beyla",
+					Subject:     "routes." + key,
+					Location:    c.Location(pattern),
+					Message:     fmt.Sprintf("routes.%s contains '%s', which doesn't start with '/', so it never matches", key, pattern.Value),
+					Remediation: "Use the path of the route, with parameters as :name or {name}, e.g. /users/:id",
+					DocURL:      routesDocURL,
+				})
+			}
+		}
+	}
+
+	if mode := c.GetString("routes", "ignore_mode"); mode != "" && !slices.Contains(ignoreModes, mode) {
+		report.Add(utils.Finding{
+			CheckID:     routesCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Beyla",
+			Subject:     "routes.ignore_mode",
+			Location:    c.Location(c.Get("routes", "ignore_mode")),
+			Message:     fmt.Sprintf("routes.ignore_mode is set to '%s', which is not a valid mode", mode),
+			Remediation: "Set it to 'traces' or 'metrics', or remove it to ignore the routes in both",
+			DocURL:      routesDocURL,
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package beyla
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:   "no routes",
+			config: "open_port: 8080",
+		},
+		{
+			name: "valid",
+			config: `
+routes:
+  patterns: [/users/:id, "/orders/{id}"]
+  ignored_patterns: [/health]
+  ignore_mode: traces
+  unmatched: heuristic
+`,
+		},
+		{
+			name: "unmatched path",
+			config: `
+routes:
+  unmatched: path
+`,
+			wantWarnings: []string{"routes.unmatched"},
+		},
+		{
+			name: "invalid",
+			config: `
+routes:
+  unmatched: all
+  patterns: [users/:id, /orders]
+  ignored_patterns: /health
+  ignore_mode: logs
+`,
+			wantErrors: []string{"routes.unmatched", "routes.patterns", "routes.ignored_patterns", "routes.ignore_mode"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("beyla-config.yml", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			checkRoutes(report, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
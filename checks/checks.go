@@ -1,48 +1,27 @@
 package checks
 
 import (
-	"otel-checker/checks/alloy"
-	"otel-checker/checks/beyla"
-	"otel-checker/checks/collector"
-	"otel-checker/checks/grafana"
-	"otel-checker/checks/sdk"
+	"os"
+
+	_ "otel-checker/checks/alloy"
+	_ "otel-checker/checks/beyla"
+	_ "otel-checker/checks/collector"
+	_ "otel-checker/checks/grafana"
+	_ "otel-checker/checks/sdk"
 	"otel-checker/checks/utils"
 )
 
-func RunAllChecks() map[string][]string {
-	messages := utils.CreateMessagesMap()
-	commands := utils.GetArguments()
-
-	grafana.CheckGrafanaSetup(&messages, commands.Language, commands.Components)
-
-	for _, c := range commands.Components {
-		if c == "alloy" {
-			alloy.CheckAlloySetup(&messages, commands.Language)
-		}
-
-		if c == "beyla" {
-			beyla.CheckBeylaSetup(&messages, commands.Language)
-		}
-
-		if c == "collector" {
-			collector.CheckCollectorSetup(
-				&messages,
-				commands.Language,
-				commands.CollectorConfigPath,
-			)
-		}
-
-		if c == "sdk" {
-			sdk.CheckSDKSetup(
-				&messages,
-				commands.Language,
-				commands.AutoInstrumentation,
-				commands.PackageJsonPath,
-				commands.InstrumentationFile,
-			)
-		}
+func RunAllChecks(commands utils.Commands) (*utils.Report, error) {
+	report, err := Run(utils.DefaultEnv(), commands)
+	if err != nil {
+		return report, err
 	}
+	return report, utils.PrintReport(os.Stdout, report, commands)
+}
 
-	utils.PrintResults(messages)
-	return messages
+// Run runs all the checks selected by commands against env, without printing the results.
+func Run(env utils.Env, commands utils.Commands) (*utils.Report, error) {
+	report := utils.NewReport()
+	err := utils.RunChecks(report, env, commands)
+	return report, err
 }
@@ -0,0 +1,254 @@
+package collector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const authCheckID = "collector.auth"
+
+// Authenticator extensions that can be used to send data to Grafana Cloud.
+var clientAuthenticators = []string{"basicauth", "bearertokenauth", "oauth2client"}
+
+var instanceID = regexp.MustCompile(`^[0-9]+$`)
+
+// checkExporterAuth checks the credentials used by the OTLP exporters, set either
+// with an authenticator extension or with the Authorization header.
+func checkExporterAuth(report *utils.Report, c *Config) {
+	for _, exporterType := range []string{"otlphttp", "otlp"} {
+		for _, e := range c.ComponentsOfType(EXPORTERS, exporterType) {
+			authenticator := e.GetString("auth", "authenticator")
+			if authenticator == "" {
+				checkAuthorizationHeader(report, c, e)
+				continue
+			}
+			checkAuthenticator(report, c, e, authenticator)
+			if e.Has("headers", "Authorization") {
+				report.Add(utils.Finding{
+					CheckID:     authCheckID,
+					Severity:    utils.WARNINGS,
+					Component:   "Collector",
+					Subject:     e.Path("headers", "Authorization"),
+					Location:    c.Location(e.Get("headers", "Authorization")),
+					Message:     fmt.Sprintf("Exporter %s sets both auth > authenticator and the Authorization header. The header is replaced by the authenticator", e.ID),
+					Remediation: fmt.Sprintf("Remove exporters > %s > headers > Authorization", e.ID),
+					DocURL:      collectorDocURL,
+				})
+			}
+		}
+	}
+}
+
+func checkAuthenticator(report *utils.Report, c *Config, e *Component, authenticator string) {
+	node, path := e.Get("auth", "authenticator"), e.Path("auth", "authenticator")
+	ext, ok := c.Extensions[authenticator]
+	if !ok {
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("Exporter %s uses the authenticator %s, which is not defined on extensions", e.ID, authenticator),
+			Remediation: fmt.Sprintf("Define the extension %s, e.g. a basicauth extension with the instance ID and token of your Grafana Cloud stack", authenticator),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	if !slices.Contains(c.Service.Extensions, authenticator) {
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("Exporter %s uses the authenticator %s, which is not enabled on service > extensions, so the collector fails to start", e.ID, authenticator),
+			Remediation: fmt.Sprintf("Add %s to service > extensions", authenticator),
+			DocURL:      collectorDocURL,
+		})
+	}
+
+	switch ext.ID.Type {
+	case "basicauth":
+		checkBasicAuth(report, c, ext)
+	case "bearertokenauth":
+		if !ext.Has("token") && !ext.Has("filename") {
+			report.Add(utils.Finding{
+				CheckID:     authCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     ext.Path("token"),
+				Location:    c.Location(ext.Key),
+				Message:     fmt.Sprintf("Extension %s has neither a token nor a filename", ext.ID),
+				Remediation: fmt.Sprintf("Set extensions > %s > token", ext.ID),
+				DocURL:      collectorDocURL,
+			})
+		}
+	case "oauth2client":
+		for _, key := range []string{"client_id", "token_url"} {
+			if ext.GetString(key) == "" {
+				report.Add(utils.Finding{
+					CheckID:     authCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Collector",
+					Subject:     ext.Path(key),
+					Location:    c.Location(ext.Key),
+					Message:     fmt.Sprintf("Extension %s has no %s", ext.ID, key),
+					Remediation: fmt.Sprintf("Set extensions > %s > %s", ext.ID, key),
+					DocURL:      collectorDocURL,
+				})
+			}
+		}
+		if !ext.Has("client_secret") && !ext.Has("client_secret_file") {
+			report.Add(utils.Finding{
+				CheckID:     authCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     ext.Path("client_secret"),
+				Location:    c.Location(ext.Key),
+				Message:     fmt.Sprintf("Extension %s has neither a client_secret nor a client_secret_file", ext.ID),
+				Remediation: fmt.Sprintf("Set extensions > %s > client_secret", ext.ID),
+				DocURL:      collectorDocURL,
+			})
+		}
+	default:
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("Exporter %s uses the authenticator %s. Grafana Cloud expects one of %s", e.ID, authenticator, strings.Join(clientAuthenticators, ", ")),
+			Remediation: "Use a basicauth extension with the instance ID and token of your Grafana Cloud stack",
+			DocURL:      collectorDocURL,
+		})
+	}
+}
+
+func checkBasicAuth(report *utils.Report, c *Config, ext *Component) {
+	if !ext.Has("client_auth") {
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     ext.Path("client_auth"),
+			Location:    c.Location(ext.Key),
+			Message:     fmt.Sprintf("Extension %s has no client_auth, so it can't be used by exporters", ext.ID),
+			Remediation: fmt.Sprintf("Set extensions > %s > client_auth > username and password to the instance ID and token of your Grafana Cloud stack", ext.ID),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	checkInstanceID(report, c, ext.Get("client_auth", "username"), ext.Path("client_auth", "username"), ext.GetString("client_auth", "username"), fmt.Sprintf("Username of extension %s", ext.ID))
+	if ext.GetString("client_auth", "password") == "" {
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     ext.Path("client_auth", "password"),
+			Location:    c.Location(ext.Key),
+			Message:     fmt.Sprintf("Extension %s has no password", ext.ID),
+			Remediation: fmt.Sprintf("Set extensions > %s > client_auth > password to a Grafana Cloud access policy token", ext.ID),
+			DocURL:      collectorDocURL,
+		})
+	}
+}
+
+func checkAuthorizationHeader(report *utils.Report, c *Config, e *Component) {
+	header := e.GetString("headers", "Authorization")
+	node, path := e.Get("headers", "Authorization"), e.Path("headers", "Authorization")
+	if header == "" {
+		if strings.Contains(e.GetString("endpoint"), "grafana.net") {
+			report.Add(utils.Finding{
+				CheckID:     authCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     e.Path("auth", "authenticator"),
+				Location:    c.Location(e.Key),
+				Message:     fmt.Sprintf("Exporter %s sends data to Grafana Cloud without credentials", e.ID),
+				Remediation: fmt.Sprintf("Set exporters > %s > auth > authenticator to a basicauth extension with the instance ID and token of your Grafana Cloud stack", e.ID),
+				DocURL:      collectorDocURL,
+			})
+		}
+		return
+	}
+	if strings.Contains(header, "${") {
+		return
+	}
+
+	scheme, credentials, _ := strings.Cut(header, " ")
+	if scheme != "Basic" {
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("Authorization header of exporter %s uses the %s scheme. Grafana Cloud expects Basic", e.ID, scheme),
+			Remediation: "Set the header to Basic followed by the base64 encoding of <instance ID>:<token>",
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(credentials))
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if err != nil || !ok || password == "" {
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("Authorization header of exporter %s is not the base64 encoding of <instance ID>:<token>", e.ID),
+			Remediation: "Copy the OTLP headers from the OpenTelemetry section of your Grafana Cloud stack",
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	checkInstanceID(report, c, node, path, username, fmt.Sprintf("Username of the Authorization header of exporter %s", e.ID))
+}
+
+// checkInstanceID checks that a username looks like the numeric ID of a Grafana Cloud instance.
+func checkInstanceID(report *utils.Report, c *Config, node *yaml.Node, path string, username string, description string) {
+	switch {
+	case username == "":
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("%s is empty", description),
+			Remediation: "Set the username to the instance ID of your Grafana Cloud stack",
+			DocURL:      collectorDocURL,
+		})
+	case strings.Contains(username, "${"):
+	case !instanceID.MatchString(username):
+		report.Add(utils.Finding{
+			CheckID:     authCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("%s is %s, which does not look like a Grafana Cloud instance ID", description, username),
+			Remediation: "Set the username to the instance ID of your Grafana Cloud stack, which is a number",
+			DocURL:      collectorDocURL,
+		})
+	default:
+		report.Add(utils.Finding{
+			CheckID:   authCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Collector",
+			Subject:   path,
+			Location:  c.Location(node),
+			Message:   fmt.Sprintf("%s is a Grafana Cloud instance ID", description),
+		})
+	}
+}
@@ -1,118 +1,197 @@
 package collector
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"otel-checker/checks/utils"
 	"regexp"
-	"slices"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
-func CheckCollectorSetup(messages *map[string][]string, language string, configPath string) {
-	checkCollectorConfig(messages, configPath)
+func init() {
+	utils.RegisterCheck(utils.NewCheck("collector", "collector", nil, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckCollectorSetup(report, env, commands)
+	}))
 }
 
-type configFile struct {
-	Receivers struct {
-		Otlp struct {
-			Protocols struct {
-				Grpc *string `yaml:"grpc"`
-				Http *string `yaml:"http"`
-			} `yaml:"protocols"`
-		} `yaml:"otlp"`
-	} `yaml:"receivers"`
-	Exporters struct {
-		Otlphttp struct {
-			Endpoint string                 `yaml:"endpoint"`
-			Auth     map[string]interface{} `yaml:"auth"`
-		} `yaml:"otlphttp"`
-	} `yaml:"exporters"`
-	Service struct {
-		Pipelines struct {
-			Traces struct {
-				Receivers  []string `yaml:"receivers"`
-				Processors []string `yaml:"processors"`
-				Exporters  []string `yaml:"exporters"`
-			} `yaml:"traces"`
-			Logs struct {
-				Receivers  []string `yaml:"receivers"`
-				Processors []string `yaml:"processors"`
-				Exporters  []string `yaml:"exporters"`
-			} `yaml:"logs"`
-			Metrics struct {
-				Receivers  []string `yaml:"receivers"`
-				Processors []string `yaml:"processors"`
-				Exporters  []string `yaml:"exporters"`
-			} `yaml:"metrics"`
-		} `yaml:"pipelines"`
-	} `yaml:"service"`
+// CheckCollectorSetup checks the collector config loaded from the sources of commands, which are
+// merged in order, and the collector binary or builder config it is used with.
+func CheckCollectorSetup(report *utils.Report, env utils.Env, commands utils.Commands) {
+	c := checkCollectorConfig(report, env, commands.CollectorConfigSources())
+	if c == nil {
+		return
+	}
+	checkDistribution(report, env, c, commands.CollectorBinary, commands.CollectorBuilderConfig)
 }
 
-func checkCollectorConfig(messages *map[string][]string, configPath string) {
-	filePath := configPath + "config.yaml"
-	yamlFile, err := os.ReadFile(filePath)
+var signals = []string{"traces", "logs", "metrics"}
+
+// checkCollectorConfig loads and checks the config, returning nil when it can't be loaded.
+func checkCollectorConfig(report *utils.Report, env utils.Env, sources []string) *Config {
+	c, err := LoadConfig(env, sources)
 	if err != nil {
-		utils.AddError(messages, "Collector", fmt.Sprintf("Could not check file %s: %s", filePath, err))
-	} else {
-		var c configFile
-		err = yaml.Unmarshal([]byte(yamlFile), &c)
-		if err != nil {
-			utils.AddError(messages, "Collector", fmt.Sprintf("Could not parse file %s: %s", filePath, err))
-			return
+		f := utils.Finding{
+			Severity:  utils.ERRORS,
+			Component: "Collector",
+			Message:   fmt.Sprintf("Could not load the collector config: %s", err),
 		}
+		var sourceErr *SourceError
+		if errors.As(err, &sourceErr) {
+			f.Message = fmt.Sprintf("Could not load collector config %s: %s", sourceErr.Source, sourceErr.Err)
+			if file, ok := utils.ConfigFile(sourceErr.Source); ok {
+				f.Location = &utils.Location{File: file}
+			}
+		}
+		report.Add(f)
+		return nil
+	}
+
+	for _, ref := range c.Unresolved {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     ref.Path,
+			Location:    c.Location(ref.Node),
+			Message:     fmt.Sprintf("Could not resolve %s on %s: %s", ref.Reference, ref.Path, ref.Err),
+			Remediation: "Set the environment variable or fix the reference before starting the collector. Use $$ to write a literal $",
+		})
+	}
+
+	checkOtlpReceivers(report, c)
+	checkReceiverEndpoints(report, env, c)
+	checkOtlphttpExporters(report, c)
+	checkExporterAuth(report, c)
+	for _, signal := range signals {
+		checkPipelines(report, c, signal)
+	}
+	checkPipelineGraph(report, c)
+	checkProcessors(report, c)
+	checkTelemetry(report, c)
+	return c
+}
 
-		if c.Receivers.Otlp.Protocols.Http == nil {
-			utils.AddWarning(messages, "Collector", "The value of receivers > otlp > protocols > http is nil. Make sure the key exists on your config.yaml")
+func checkOtlpReceivers(report *utils.Report, c *Config) {
+	for _, r := range c.ComponentsOfType(RECEIVERS, "otlp") {
+		if !r.Has("protocols", "http") {
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "Collector",
+				Subject:   r.Path("protocols", "http"),
+				Location:  c.Location(r.Key),
+				Message:   fmt.Sprintf("The value of receivers > %s > protocols > http is nil. Make sure the key exists on your config", r.ID),
+			})
 		}
+	}
+}
+
+func checkOtlphttpExporters(report *utils.Report, c *Config) {
+	exporters := c.ComponentsOfType(EXPORTERS, "otlphttp")
+	if len(exporters) == 0 {
+		report.Add(utils.Finding{
+			Severity:  utils.ERRORS,
+			Component: "Collector",
+			Subject:   EXPORTERS,
+			Location:  c.Location(nil),
+			Message:   "No otlphttp exporter is defined on the config. Add an otlphttp exporter with an endpoint similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp",
+		})
+		return
+	}
 
-		match, _ := regexp.MatchString("https:\\/\\/.+\\.grafana\\.net\\/otlp", c.Exporters.Otlphttp.Endpoint)
+	for _, e := range exporters {
+		endpoint := e.GetString("endpoint")
+		node := e.Key
+		if n := e.Get("endpoint"); n != nil {
+			node = n
+		}
+		match, _ := regexp.MatchString("https:\\/\\/.+\\.grafana\\.net\\/otlp", endpoint)
 		if match {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of exporter > otlphttp > endpoint on config.yaml set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp")
+			report.Add(utils.Finding{
+				Severity:  utils.CHECKS,
+				Component: "Collector",
+				Subject:   e.Path("endpoint"),
+				Location:  c.Location(node),
+				Message:   fmt.Sprintf("Value of exporters > %s > endpoint set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp", e.ID),
+			})
 		} else {
-			if strings.Contains(c.Exporters.Otlphttp.Endpoint, "localhost") {
-				utils.AddWarning(messages, "Collector", "Value of exporter > otlphttp > endpoint on config.yaml is set to localhost. Update to a Grafana endpoint similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp to be able to send telemetry to your Grafana Cloud instance")
+			if strings.Contains(endpoint, "localhost") {
+				report.Add(utils.Finding{
+					Severity:  utils.WARNINGS,
+					Component: "Collector",
+					Subject:   e.Path("endpoint"),
+					Location:  c.Location(node),
+					Message:   fmt.Sprintf("Value of exporters > %s > endpoint is set to localhost. Update to a Grafana endpoint similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp to be able to send telemetry to your Grafana Cloud instance", e.ID),
+				})
 			} else {
-				utils.AddError(messages, "Collector", "Value of exporter > otlphttp > endpoint on config.yaml is not set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp")
+				report.Add(utils.Finding{
+					Severity:  utils.ERRORS,
+					Component: "Collector",
+					Subject:   e.Path("endpoint"),
+					Location:  c.Location(node),
+					Message:   fmt.Sprintf("Value of exporters > %s > endpoint is not set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp", e.ID),
+				})
 			}
 		}
+	}
+}
 
-		// Traces
-		if slices.Contains(c.Service.Pipelines.Traces.Exporters, "otlphttp") {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > traces > exporters on config.yaml contains otlphttp")
-		} else {
-			utils.AddWarning(messages, "Collector", "Value of service > pipelines > traces > exporters on config.yaml does not contain otlphttp")
-		}
-		if slices.Contains(c.Service.Pipelines.Traces.Receivers, "otlp") {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > traces > receivers on config.yaml contains otlp")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > traces > receivers on config.yaml does not contain otlp")
-		}
+// checkPipelines checks that the pipelines of a signal receive OTLP data and export it with otlphttp.
+func checkPipelines(report *utils.Report, c *Config, signal string) {
+	pipelines := c.PipelinesOfSignal(signal)
+	if len(pipelines) == 0 {
+		report.Add(utils.Finding{
+			Severity:  utils.WARNINGS,
+			Component: "Collector",
+			Subject:   "service.pipelines." + signal,
+			Location:  c.Location(c.Service.Key),
+			Message:   fmt.Sprintf("No %s pipeline defined on service > pipelines, so %s are not sent to Grafana Cloud", signal, signal),
+		})
+		return
+	}
 
-		// Logs
-		if slices.Contains(c.Service.Pipelines.Logs.Exporters, "otlphttp") {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > logs > exporters on config.yaml contains otlphttp")
+	for _, p := range pipelines {
+		if exporter := firstOfType(p.Exporters, "otlphttp"); exporter != "" {
+			report.Add(utils.Finding{
+				Severity:  utils.CHECKS,
+				Component: "Collector",
+				Subject:   p.Path(EXPORTERS),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Value of service > pipelines > %s > exporters contains %s", p.ID, exporter),
+			})
 		} else {
-			utils.AddWarning(messages, "Collector", "Value of service > pipelines > logs > exporters on config.yaml does not contain otlphttp")
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "Collector",
+				Subject:   p.Path(EXPORTERS),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Value of service > pipelines > %s > exporters does not contain otlphttp", p.ID),
+			})
 		}
-		if slices.Contains(c.Service.Pipelines.Logs.Receivers, "otlp") {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > logs > receivers on config.yaml contains otlp")
+		if receiver := firstOfType(p.Receivers, "otlp"); receiver != "" {
+			report.Add(utils.Finding{
+				Severity:  utils.CHECKS,
+				Component: "Collector",
+				Subject:   p.Path(RECEIVERS),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Value of service > pipelines > %s > receivers contains %s", p.ID, receiver),
+			})
 		} else {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > logs > receivers on config.yaml does not contain otlp")
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "Collector",
+				Subject:   p.Path(RECEIVERS),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Value of service > pipelines > %s > receivers does not contain otlp", p.ID),
+			})
 		}
+	}
+}
 
-		// Metrics
-		if slices.Contains(c.Service.Pipelines.Metrics.Exporters, "otlphttp") {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > metrics > exporters on config.yaml contains otlphttp")
-		} else {
-			utils.AddWarning(messages, "Collector", "Value of service > pipelines > metrics > exporters on config.yaml does not contain otlphttp")
-		}
-		if slices.Contains(c.Service.Pipelines.Metrics.Receivers, "otlp") {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > metrics > receivers on config.yaml contains otlp")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Collector", "Value of service > pipelines > metrics > receivers on config.yaml does not contain otlp")
+// firstOfType returns the first ID in ids with the given component type, such as otlphttp for otlphttp/grafana.
+func firstOfType(ids []string, componentType string) string {
+	for _, id := range ids {
+		if ParseComponentID(id).Type == componentType {
+			return id
 		}
 	}
+	return ""
 }
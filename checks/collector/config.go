@@ -0,0 +1,324 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of components that can be defined on the top level of a collector config.
+const RECEIVERS = "receivers"
+const PROCESSORS = "processors"
+const EXPORTERS = "exporters"
+const CONNECTORS = "connectors"
+const EXTENSIONS = "extensions"
+
+var componentKinds = []string{RECEIVERS, PROCESSORS, EXPORTERS, CONNECTORS, EXTENSIONS}
+
+// ComponentID identifies a component or pipeline, in the format type[/name].
+type ComponentID struct {
+	Type string
+	Name string
+}
+
+func ParseComponentID(id string) ComponentID {
+	t, name, _ := strings.Cut(id, "/")
+	return ComponentID{Type: strings.TrimSpace(t), Name: strings.TrimSpace(name)}
+}
+
+func (id ComponentID) String() string {
+	if id.Name == "" {
+		return id.Type
+	}
+	return id.Type + "/" + id.Name
+}
+
+// Component is a receiver, processor, exporter, connector or extension defined in the config.
+type Component struct {
+	ID   ComponentID
+	Kind string
+	// Key is the node with the ID of the component, and Node its value, which
+	// is a null node when the component has no settings.
+	Key  *yaml.Node
+	Node *yaml.Node
+}
+
+// Get returns the node at path inside the settings of the component, or nil when it doesn't exist.
+func (c *Component) Get(path ...string) *yaml.Node {
+	return lookup(c.Node, path...)
+}
+
+// Has returns whether the key at path exists, even when it has no value.
+func (c *Component) Has(path ...string) bool {
+	return c.Get(path...) != nil
+}
+
+// GetString returns the scalar value at path, or "" when it doesn't exist.
+func (c *Component) GetString(path ...string) string {
+	n := c.Get(path...)
+	if n == nil || n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+		return ""
+	}
+	return n.Value
+}
+
+// Path returns the YAML path of the component, or of a key inside its settings.
+func (c *Component) Path(path ...string) string {
+	return strings.Join(append([]string{c.Kind, c.ID.String()}, path...), ".")
+}
+
+// Pipeline is an entry of service.pipelines.
+type Pipeline struct {
+	// ID.Type is the signal of the pipeline: traces, metrics or logs.
+	ID         ComponentID
+	Receivers  []string
+	Processors []string
+	Exporters  []string
+	Key        *yaml.Node
+	// Nodes has the node of each entry in Receivers, Processors and Exporters, by kind.
+	Nodes map[string][]*yaml.Node
+}
+
+func (p *Pipeline) Path(path ...string) string {
+	return strings.Join(append([]string{"service", "pipelines", p.ID.String()}, path...), ".")
+}
+
+type Service struct {
+	Extensions []string
+	// ExtensionNodes has the node of each entry in Extensions.
+	ExtensionNodes []*yaml.Node
+	Pipelines      map[string]*Pipeline
+	Telemetry      *yaml.Node
+	Key            *yaml.Node
+}
+
+// Config is a collector configuration, with components keyed by their type[/name] ID.
+type Config struct {
+	// Sources are the files or URIs the config was loaded from.
+	Sources    []string
+	Receivers  map[string]*Component
+	Processors map[string]*Component
+	Exporters  map[string]*Component
+	Connectors map[string]*Component
+	Extensions map[string]*Component
+	Service    Service
+	// Unresolved are the ${...} references that could not be expanded by LoadConfig.
+	Unresolved []UnresolvedReference
+
+	// files has the source each node was loaded from.
+	files map[*yaml.Node]string
+}
+
+// ParseConfig parses the content of a single collector config file, without expanding ${...} references.
+func ParseConfig(file string, data []byte) (*Config, error) {
+	files := map[*yaml.Node]string{}
+	root, err := parseSource(file, data, files)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig([]string{file}, root, files)
+}
+
+func newConfig(sources []string, root *yaml.Node, files map[*yaml.Node]string) (*Config, error) {
+	c := &Config{
+		Sources:    sources,
+		files:      files,
+		Receivers:  map[string]*Component{},
+		Processors: map[string]*Component{},
+		Exporters:  map[string]*Component{},
+		Connectors: map[string]*Component{},
+		Extensions: map[string]*Component{},
+		Service:    Service{Pipelines: map[string]*Pipeline{}},
+	}
+	if root == nil || isNull(root) {
+		return c, nil
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: the config must be a map", root.Line)
+	}
+
+	for _, kind := range componentKinds {
+		key, value := lookupKey(root, kind)
+		if value == nil || isNull(value) {
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: %s must be a map", key.Line, kind)
+		}
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			id := value.Content[i].Value
+			c.components(kind)[id] = &Component{
+				ID:   ParseComponentID(id),
+				Kind: kind,
+				Key:  value.Content[i],
+				Node: value.Content[i+1],
+			}
+		}
+	}
+
+	key, service := lookupKey(root, "service")
+	if service == nil || isNull(service) {
+		return c, nil
+	}
+	if service.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: service must be a map", key.Line)
+	}
+	c.Service.Key = key
+	c.Service.Telemetry = lookup(service, "telemetry")
+
+	extensions, extensionNodes, err := stringList(lookup(service, "extensions"))
+	if err != nil {
+		return nil, fmt.Errorf("service.extensions: %w", err)
+	}
+	c.Service.Extensions = extensions
+	c.Service.ExtensionNodes = extensionNodes
+
+	key, pipelines := lookupKey(service, "pipelines")
+	if pipelines == nil || isNull(pipelines) {
+		return c, nil
+	}
+	if pipelines.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: service.pipelines must be a map", key.Line)
+	}
+	for i := 0; i+1 < len(pipelines.Content); i += 2 {
+		id := pipelines.Content[i].Value
+		p := &Pipeline{ID: ParseComponentID(id), Key: pipelines.Content[i], Nodes: map[string][]*yaml.Node{}}
+		for _, kind := range []string{RECEIVERS, PROCESSORS, EXPORTERS} {
+			values, nodes, err := stringList(lookup(pipelines.Content[i+1], kind))
+			if err != nil {
+				return nil, fmt.Errorf("service.pipelines.%s.%s: %w", id, kind, err)
+			}
+			p.Nodes[kind] = nodes
+			switch kind {
+			case RECEIVERS:
+				p.Receivers = values
+			case PROCESSORS:
+				p.Processors = values
+			case EXPORTERS:
+				p.Exporters = values
+			}
+		}
+		c.Service.Pipelines[id] = p
+	}
+	return c, nil
+}
+
+func (c *Config) components(kind string) map[string]*Component {
+	switch kind {
+	case RECEIVERS:
+		return c.Receivers
+	case PROCESSORS:
+		return c.Processors
+	case EXPORTERS:
+		return c.Exporters
+	case CONNECTORS:
+		return c.Connectors
+	case EXTENSIONS:
+		return c.Extensions
+	}
+	return nil
+}
+
+// ComponentsOfKind returns the components of a kind, such as RECEIVERS, sorted by ID.
+func (c *Config) ComponentsOfKind(kind string) []*Component {
+	var found []*Component
+	for _, id := range sortedKeys(c.components(kind)) {
+		found = append(found, c.components(kind)[id])
+	}
+	return found
+}
+
+// ComponentsOfType returns the components of a kind with the given type, sorted by ID.
+func (c *Config) ComponentsOfType(kind string, componentType string) []*Component {
+	var found []*Component
+	for _, id := range sortedKeys(c.components(kind)) {
+		if comp := c.components(kind)[id]; comp.ID.Type == componentType {
+			found = append(found, comp)
+		}
+	}
+	return found
+}
+
+// PipelinesOfSignal returns the pipelines of a signal, sorted by ID.
+func (c *Config) PipelinesOfSignal(signal string) []*Pipeline {
+	var found []*Pipeline
+	for _, id := range sortedKeys(c.Service.Pipelines) {
+		if p := c.Service.Pipelines[id]; p.ID.Type == signal {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// Location returns the file and line a node was loaded from. When n is nil,
+// it returns the first source of the config, without a line.
+func (c *Config) Location(n *yaml.Node) *utils.Location {
+	if n == nil {
+		if len(c.Sources) == 0 {
+			return nil
+		}
+		return &utils.Location{File: c.Sources[0]}
+	}
+	return &utils.Location{File: c.files[n], Line: n.Line}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// lookupKey returns the key and value nodes of a key in a mapping node.
+func lookupKey(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i], n.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// lookup returns the value node at path inside a mapping node, or nil when it doesn't exist.
+func lookup(n *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		_, n = lookupKey(n, key)
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// stringList decodes a sequence of strings, returning the node of each entry.
+func stringList(n *yaml.Node) ([]string, []*yaml.Node, error) {
+	if n == nil || isNull(n) {
+		return nil, nil, nil
+	}
+	if n.Kind != yaml.SequenceNode {
+		return nil, nil, fmt.Errorf("line %d: expected a list", n.Line)
+	}
+	values := make([]string, 0, len(n.Content))
+	nodes := make([]*yaml.Node, 0, len(n.Content))
+	for _, item := range n.Content {
+		if item.Kind != yaml.ScalarNode {
+			return nil, nil, fmt.Errorf("line %d: expected a string", item.Line)
+		}
+		values = append(values, item.Value)
+		nodes = append(nodes, item)
+	}
+	return values, nodes, nil
+}
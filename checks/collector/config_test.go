@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseComponentID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want ComponentID
+	}{
+		{id: "otlp", want: ComponentID{Type: "otlp"}},
+		{id: "otlphttp/grafana", want: ComponentID{Type: "otlphttp", Name: "grafana"}},
+		{id: "traces/2", want: ComponentID{Type: "traces", Name: "2"}},
+		{id: "filelog/app/errors", want: ComponentID{Type: "filelog", Name: "app/errors"}},
+	}
+	for _, tt := range tests {
+		got := ParseComponentID(tt.id)
+		if got != tt.want {
+			t.Errorf("ParseComponentID(%q) = %+v, want %+v", tt.id, got, tt.want)
+		}
+		if got.String() != tt.id {
+			t.Errorf("String() = %q, want %q", got.String(), tt.id)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig("config.yaml", []byte(`
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+processors:
+  batch:
+exporters:
+  otlphttp/grafana:
+    endpoint: https://otlp-gateway-prod-us-east-0.grafana.net/otlp
+connectors:
+  spanmetrics:
+extensions:
+  health_check:
+service:
+  extensions: [health_check]
+  pipelines:
+    traces/2:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp/grafana, spanmetrics]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Receivers) != 1 || len(c.Processors) != 1 || len(c.Exporters) != 1 || len(c.Connectors) != 1 || len(c.Extensions) != 1 {
+		t.Errorf("unexpected components: %+v", c)
+	}
+	otlp := c.Receivers["otlp"]
+	if !otlp.Has("protocols", "grpc") || !otlp.Has("protocols", "http") || otlp.Has("protocols", "thrift") {
+		t.Errorf("protocols of otlp not parsed correctly")
+	}
+	exporter := c.Exporters["otlphttp/grafana"]
+	if exporter.ID.Type != "otlphttp" || exporter.GetString("endpoint") != "https://otlp-gateway-prod-us-east-0.grafana.net/otlp" || exporter.Key.Line != 10 {
+		t.Errorf("exporter = %+v", exporter)
+	}
+	if !slices.Equal(c.Service.Extensions, []string{"health_check"}) {
+		t.Errorf("service extensions = %v", c.Service.Extensions)
+	}
+
+	pipelines := c.PipelinesOfSignal("traces")
+	if len(pipelines) != 1 {
+		t.Fatalf("traces pipelines = %v", pipelines)
+	}
+	p := pipelines[0]
+	if p.ID.String() != "traces/2" || !slices.Equal(p.Exporters, []string{"otlphttp/grafana", "spanmetrics"}) || p.Nodes[EXPORTERS][1].Line != 22 {
+		t.Errorf("pipeline = %+v", p)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, config := range []string{
+		"- a list",
+		"receivers: [otlp]",
+		"service:\n  pipelines:\n    traces:\n      receivers: otlp",
+	} {
+		if _, err := ParseConfig("config.yaml", []byte(config)); err == nil {
+			t.Errorf("expected an error parsing %q", config)
+		}
+	}
+	if _, err := ParseConfig("config.yaml", nil); err != nil {
+		t.Errorf("unexpected error parsing an empty config: %v", err)
+	}
+}
@@ -0,0 +1,273 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const distributionCheckID = "collector.distribution"
+
+// Oldest collector version supported by the checks. Older collectors are
+// reported with a warning, since they may not support the config syntax used
+// in the documentation.
+const minCollectorVersion = "0.100.0"
+
+// Oldest collector version including a component, by kind and type, for the
+// components the checks know about. A distribution of an older version that
+// lists the component, such as a builder config pinning an old otelcol_version,
+// can't build it.
+var minComponentVersions = map[string]map[string]string{
+	RECEIVERS: {
+		"otlp":   "0.9.0",
+		"kafka":  "0.14.0",
+		"jaeger": "0.9.0",
+		"zipkin": "0.9.0",
+	},
+	PROCESSORS: {
+		"tail_sampling":     "0.9.0",
+		"deltatocumulative": "0.94.0",
+	},
+	EXPORTERS: {
+		"otlp":     "0.9.0",
+		"otlphttp": "0.14.0",
+		"debug":    "0.86.0",
+	},
+	CONNECTORS: {
+		"forward":         "0.71.0",
+		"count":           "0.71.0",
+		"spanmetrics":     "0.71.0",
+		"servicegraph":    "0.72.0",
+		"routing":         "0.78.0",
+		"exceptions":      "0.96.0",
+		"signaltometrics": "0.120.0",
+	},
+	EXTENSIONS: {
+		"oauth2client":    "0.26.0",
+		"bearertokenauth": "0.29.0",
+		"basicauth":       "0.41.0",
+	},
+}
+
+// Distribution is the list of components included in a collector build.
+type Distribution struct {
+	// Source is the binary or builder config the distribution was read from.
+	Source string
+	// Version is the version of the collector, or "" when it is not known.
+	Version string
+	// Components has the types of the components included, by kind.
+	Components map[string][]string
+}
+
+// Includes returns whether the distribution includes a component of the given kind and type.
+func (d *Distribution) Includes(kind string, componentType string) bool {
+	for _, t := range d.Components[kind] {
+		if normalizeType(t) == normalizeType(componentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeType removes the underscores of a component type, so types such as
+// tail_sampling match the name of their module, tailsamplingprocessor.
+func normalizeType(componentType string) string {
+	return strings.ReplaceAll(componentType, "_", "")
+}
+
+// componentsOutput is the output of "otelcol components". Versions older than
+// 0.92.0 list the components as strings instead of maps.
+type componentsOutput struct {
+	BuildInfo struct {
+		Version string `yaml:"version"`
+	} `yaml:"buildinfo"`
+	Receivers  []yaml.Node `yaml:"receivers"`
+	Processors []yaml.Node `yaml:"processors"`
+	Exporters  []yaml.Node `yaml:"exporters"`
+	Connectors []yaml.Node `yaml:"connectors"`
+	Extensions []yaml.Node `yaml:"extensions"`
+}
+
+var versionOutput = regexp.MustCompile(`version v?([0-9][^\s]*)`)
+
+// DistributionFromBinary lists the components of a collector binary with "<binary> components".
+func DistributionFromBinary(env utils.Env, binary string) (*Distribution, error) {
+	stdout, err := env.Output(binary, "components")
+	if err != nil {
+		return nil, err
+	}
+	var out componentsOutput
+	if err := yaml.Unmarshal(stdout, &out); err != nil {
+		return nil, err
+	}
+
+	d := &Distribution{Source: binary, Version: out.BuildInfo.Version, Components: map[string][]string{}}
+	for kind, nodes := range map[string][]yaml.Node{RECEIVERS: out.Receivers, PROCESSORS: out.Processors, EXPORTERS: out.Exporters, CONNECTORS: out.Connectors, EXTENSIONS: out.Extensions} {
+		for _, n := range nodes {
+			name := n.Value
+			if n.Kind == yaml.MappingNode {
+				name = scalar(lookup(&n, "name"))
+			}
+			d.Components[kind] = append(d.Components[kind], name)
+		}
+	}
+
+	if d.Version == "" {
+		if stdout, err := env.Output(binary, "--version"); err == nil {
+			if m := versionOutput.FindSubmatch(stdout); m != nil {
+				d.Version = string(m[1])
+			}
+		}
+	}
+	return d, nil
+}
+
+// builderConfig is the part of the ocb builder config listing the components.
+type builderConfig struct {
+	Dist struct {
+		OtelcolVersion string `yaml:"otelcol_version"`
+	} `yaml:"dist"`
+	Receivers  []builderModule `yaml:"receivers"`
+	Processors []builderModule `yaml:"processors"`
+	Exporters  []builderModule `yaml:"exporters"`
+	Connectors []builderModule `yaml:"connectors"`
+	Extensions []builderModule `yaml:"extensions"`
+}
+
+type builderModule struct {
+	GoMod string `yaml:"gomod"`
+}
+
+// DistributionFromBuilderConfig lists the components of the collector built with an ocb builder config.
+func DistributionFromBuilderConfig(env utils.Env, file string) (*Distribution, error) {
+	data, err := env.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var config builderConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	d := &Distribution{Source: file, Version: config.Dist.OtelcolVersion, Components: map[string][]string{}}
+	suffixes := map[string]string{RECEIVERS: "receiver", PROCESSORS: "processor", EXPORTERS: "exporter", CONNECTORS: "connector", EXTENSIONS: "extension"}
+	for kind, modules := range map[string][]builderModule{RECEIVERS: config.Receivers, PROCESSORS: config.Processors, EXPORTERS: config.Exporters, CONNECTORS: config.Connectors, EXTENSIONS: config.Extensions} {
+		for _, m := range modules {
+			module, version, _ := strings.Cut(strings.TrimSpace(m.GoMod), " ")
+			d.Components[kind] = append(d.Components[kind], strings.TrimSuffix(path.Base(module), suffixes[kind]))
+			// Versions of ocb older than 0.110.0 need dist.otelcol_version, while newer ones
+			// use the version of the core modules.
+			if d.Version == "" && strings.HasPrefix(module, "go.opentelemetry.io/collector/") {
+				d.Version = strings.TrimPrefix(version, "v")
+			}
+		}
+	}
+	return d, nil
+}
+
+// checkDistribution checks that the collector built from binary or builderConfig
+// includes every component of the config, and that its version is supported.
+func checkDistribution(report *utils.Report, env utils.Env, c *Config, binary string, builderConfig string) {
+	var d *Distribution
+	var err error
+	switch {
+	case binary != "":
+		d, err = DistributionFromBinary(env, binary)
+	case builderConfig != "":
+		d, err = DistributionFromBuilderConfig(env, builderConfig)
+	default:
+		return
+	}
+	if err != nil {
+		source := binary
+		if source == "" {
+			source = builderConfig
+		}
+		report.Add(utils.Finding{
+			CheckID:   distributionCheckID,
+			Severity:  utils.ERRORS,
+			Component: "Collector",
+			Subject:   source,
+			Location:  c.Location(nil),
+			Message:   fmt.Sprintf("Could not list the components of the collector %s: %s", source, err),
+		})
+		return
+	}
+
+	complete := true
+	for _, kind := range componentKinds {
+		for _, id := range sortedKeys(c.components(kind)) {
+			comp := c.components(kind)[id]
+			if !d.Includes(kind, comp.ID.Type) {
+				complete = false
+				report.Add(utils.Finding{
+					CheckID:     distributionCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Collector",
+					Subject:     comp.Path(),
+					Location:    c.Location(comp.Key),
+					Message:     fmt.Sprintf("%s %s is not included in the collector %s, so the collector fails to start", kindName(kind), id, d.Source),
+					Remediation: fmt.Sprintf("Use a collector distribution including the %s %s, such as otelcol-contrib, or add it to the builder config", comp.ID.Type, strings.ToLower(kindName(kind))),
+				})
+				continue
+			}
+			if minVersion, ok := minComponentVersions[kind][comp.ID.Type]; ok && utils.CompareVersions(d.Version, minVersion) < 0 {
+				report.Add(utils.Finding{
+					CheckID:     distributionCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Collector",
+					Subject:     comp.Path(),
+					Location:    c.Location(comp.Key),
+					Message:     fmt.Sprintf("%s %s needs collector %s or newer, but the collector %s is version %s", kindName(kind), id, minVersion, d.Source, d.Version),
+					Remediation: fmt.Sprintf("Upgrade the collector to version %s or newer", minVersion),
+				})
+			}
+		}
+	}
+
+	switch {
+	case !utils.ValidVersion(d.Version):
+		report.Add(utils.Finding{
+			CheckID:   distributionCheckID,
+			Severity:  utils.WARNINGS,
+			Component: "Collector",
+			Subject:   d.Source,
+			Location:  c.Location(nil),
+			Message:   fmt.Sprintf("Could not find the version of the collector %s", d.Source),
+		})
+	case utils.CompareVersions(d.Version, minCollectorVersion) < 0:
+		report.Add(utils.Finding{
+			CheckID:     distributionCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     d.Source,
+			Location:    c.Location(nil),
+			Message:     fmt.Sprintf("The collector %s is version %s, which is older than %s. Older versions miss fixes and may not support the config syntax used in the documentation", d.Source, d.Version, minCollectorVersion),
+			Remediation: fmt.Sprintf("Upgrade the collector to version %s or newer", minCollectorVersion),
+		})
+	default:
+		report.Add(utils.Finding{
+			CheckID:   distributionCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Collector",
+			Subject:   d.Source,
+			Location:  c.Location(nil),
+			Message:   fmt.Sprintf("The collector %s is version %s", d.Source, d.Version),
+		})
+	}
+	if complete {
+		report.Add(utils.Finding{
+			CheckID:   distributionCheckID,
+			Severity:  utils.CHECKS,
+			Component: "Collector",
+			Subject:   d.Source,
+			Location:  c.Location(nil),
+			Message:   fmt.Sprintf("The collector %s includes every component of the config", d.Source),
+		})
+	}
+}
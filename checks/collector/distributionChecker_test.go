@@ -0,0 +1,195 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+const distributionConfig = `
+receivers:
+  otlp:
+processors:
+  tail_sampling:
+  memory_limiter:
+exporters:
+  otlphttp:
+connectors:
+  signaltometrics:
+extensions:
+  basicauth/grafana:
+  health_check:
+`
+
+const otelcolComponents = `buildinfo:
+    command: otelcol
+    description: OpenTelemetry Collector
+    version: 0.119.0
+receivers:
+    - name: otlp
+      module: go.opentelemetry.io/collector/receiver/otlpreceiver v0.119.0
+processors:
+    - name: memory_limiter
+      module: go.opentelemetry.io/collector/processor/memorylimiterprocessor v0.119.0
+    - name: batch
+      module: go.opentelemetry.io/collector/processor/batchprocessor v0.119.0
+exporters:
+    - name: otlphttp
+      module: go.opentelemetry.io/collector/exporter/otlphttpexporter v0.119.0
+connectors:
+    - name: signaltometrics
+      module: github.com/open-telemetry/opentelemetry-collector-contrib/connector/signaltometricsconnector v0.119.0
+extensions:
+    - name: health_check
+      module: github.com/open-telemetry/opentelemetry-collector-contrib/extension/healthcheckextension v0.119.0
+`
+
+const builderConfigFile = `
+dist:
+  name: otelcol-grafana
+  output_path: ./otelcol-grafana
+receivers:
+  - gomod: go.opentelemetry.io/collector/receiver/otlpreceiver v0.121.0
+processors:
+  - gomod: go.opentelemetry.io/collector/processor/memorylimiterprocessor v0.121.0
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/processor/tailsamplingprocessor v0.121.0
+exporters:
+  - gomod: go.opentelemetry.io/collector/exporter/otlphttpexporter v0.121.0
+connectors:
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/connector/signaltometricsconnector v0.121.0
+extensions:
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/extension/basicauthextension v0.121.0
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/extension/healthcheckextension v0.121.0
+`
+
+func TestCheckDistribution(t *testing.T) {
+	tests := []struct {
+		name          string
+		binary        string
+		builderConfig string
+		run           func(name string, args ...string) ([]byte, error)
+		wantErrors    []string
+		wantWarnings  []string
+		wantChecks    []string
+	}{
+		{
+			name:   "binary",
+			binary: "otelcol",
+			run: func(name string, args ...string) ([]byte, error) {
+				return []byte(otelcolComponents), nil
+			},
+			wantErrors: []string{
+				"processors.tail_sampling",
+				"connectors.signaltometrics",
+				"extensions.basicauth/grafana",
+			},
+			wantChecks: []string{"otelcol"},
+		},
+		{
+			name:   "old binary listing names",
+			binary: "otelcol-contrib",
+			run: func(name string, args ...string) ([]byte, error) {
+				if args[0] == "--version" {
+					return []byte("otelcol-contrib version 0.90.1\n"), nil
+				}
+				return []byte("receivers:\n  - otlp\nprocessors:\n  - memory_limiter\n  - tail_sampling\nexporters:\n  - otlphttp\nconnectors:\n  - signaltometrics\nextensions:\n  - basicauth\n  - health_check\n"), nil
+			},
+			wantErrors:   []string{"connectors.signaltometrics"},
+			wantWarnings: []string{"otelcol-contrib"},
+			wantChecks:   []string{"otelcol-contrib"},
+		},
+		{
+			name:          "builder config",
+			builderConfig: "builder-config.yaml",
+			wantChecks:    []string{"builder-config.yaml", "builder-config.yaml"},
+		},
+		{
+			name:   "binary not found",
+			binary: "otelcol",
+			run: func(name string, args ...string) ([]byte, error) {
+				return nil, errors.New("executable file not found in $PATH")
+			},
+			wantErrors: []string{"otelcol"},
+		},
+		{
+			name: "nothing to check",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("config.yaml", []byte(distributionConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			env := utils.Env{
+				Context:    context.Background(),
+				Getenv:     utils.MapGetenv(nil),
+				FS:         fstest.MapFS{"builder-config.yaml": {Data: []byte(builderConfigFile)}},
+				RunCommand: tt.run,
+			}
+			checkDistribution(report, env, c, tt.binary, tt.builderConfig)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			if got := utilstest.Subjects(report, utils.CHECKS); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestCheckDistributionComponentVersions(t *testing.T) {
+	c, err := ParseConfig("config.yaml", []byte(`
+receivers:
+  otlp:
+processors:
+  tail_sampling:
+exporters:
+  debug:
+connectors:
+  spanmetrics:
+  exceptions:
+extensions:
+  basicauth:
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := utils.NewReport()
+	env := utils.Env{
+		Context: context.Background(),
+		FS: fstest.MapFS{"builder-config.yaml": {Data: []byte(`
+dist:
+  otelcol_version: 0.90.0
+receivers:
+  - gomod: go.opentelemetry.io/collector/receiver/otlpreceiver v0.90.0
+processors:
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/processor/tailsamplingprocessor v0.90.0
+exporters:
+  - gomod: go.opentelemetry.io/collector/exporter/debugexporter v0.90.0
+connectors:
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/connector/spanmetricsconnector v0.90.0
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/connector/exceptionsconnector v0.90.0
+extensions:
+  - gomod: github.com/open-telemetry/opentelemetry-collector-contrib/extension/basicauthextension v0.90.0
+`)}},
+	}
+	checkDistribution(report, env, c, "", "builder-config.yaml")
+
+	if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, []string{"connectors.exceptions"}) {
+		t.Errorf("errors = %v, want [connectors.exceptions]", got)
+	}
+	if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, []string{"builder-config.yaml"}) {
+		t.Errorf("warnings = %v, want a warning for the collector version", got)
+	}
+}
@@ -0,0 +1,293 @@
+package collector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const pipelinesCheckID = "collector.pipelines"
+
+var allSignals = []string{"traces", "metrics", "logs"}
+
+// Signals supported by common components, by kind and type. Components that
+// are not listed are not checked for signal mismatches.
+var supportedSignals = map[string]map[string][]string{
+	RECEIVERS: {
+		"otlp":            allSignals,
+		"kafka":           allSignals,
+		"jaeger":          {"traces"},
+		"zipkin":          {"traces"},
+		"prometheus":      {"metrics"},
+		"hostmetrics":     {"metrics"},
+		"kubeletstats":    {"metrics"},
+		"docker_stats":    {"metrics"},
+		"statsd":          {"metrics"},
+		"k8s_cluster":     {"metrics", "logs"},
+		"k8s_events":      {"logs"},
+		"filelog":         {"logs"},
+		"journald":        {"logs"},
+		"syslog":          {"logs"},
+		"fluentforward":   {"logs"},
+		"windowseventlog": {"logs"},
+	},
+	PROCESSORS: {
+		"tail_sampling":         {"traces"},
+		"groupbytrace":          {"traces"},
+		"span":                  {"traces"},
+		"probabilistic_sampler": {"traces", "logs"},
+		"metricstransform":      {"metrics"},
+		"cumulativetodelta":     {"metrics"},
+		"deltatocumulative":     {"metrics"},
+	},
+	EXPORTERS: {
+		"otlp":                  allSignals,
+		"otlphttp":              allSignals,
+		"debug":                 allSignals,
+		"logging":               allSignals,
+		"file":                  allSignals,
+		"kafka":                 allSignals,
+		"zipkin":                {"traces"},
+		"prometheus":            {"metrics"},
+		"prometheusremotewrite": {"metrics"},
+		"loki":                  {"logs"},
+	},
+}
+
+// Signals connectors consume, when used as exporters, and produce, when used as receivers.
+var connectorSignals = map[string]struct {
+	consumes []string
+	produces []string
+}{
+	"forward":      {consumes: allSignals, produces: allSignals},
+	"routing":      {consumes: allSignals, produces: allSignals},
+	"count":        {consumes: allSignals, produces: []string{"metrics"}},
+	"spanmetrics":  {consumes: []string{"traces"}, produces: []string{"metrics"}},
+	"servicegraph": {consumes: []string{"traces"}, produces: []string{"metrics"}},
+	"exceptions":   {consumes: []string{"traces"}, produces: []string{"metrics", "logs"}},
+}
+
+// checkPipelineGraph validates the components referenced by the pipelines,
+// and the components and connectors that are not part of any pipeline.
+func checkPipelineGraph(report *utils.Report, c *Config) {
+	usage := map[string]map[string]bool{RECEIVERS: {}, PROCESSORS: {}, EXPORTERS: {}}
+	connectorUsage := map[string]map[string]bool{RECEIVERS: {}, EXPORTERS: {}}
+
+	for _, id := range sortedKeys(c.Service.Pipelines) {
+		p := c.Service.Pipelines[id]
+		if !slices.Contains(allSignals, p.ID.Type) {
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Collector",
+				Subject:   p.Path(),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Pipeline %s has an unknown signal %s. The pipeline name must start with traces, metrics or logs", p.ID, p.ID.Type),
+			})
+			continue
+		}
+		if len(p.Receivers) == 0 {
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Collector",
+				Subject:   p.Path(RECEIVERS),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Pipeline %s has no receivers, so it will never get any data", p.ID),
+			})
+		}
+		if len(p.Exporters) == 0 {
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Collector",
+				Subject:   p.Path(EXPORTERS),
+				Location:  c.Location(p.Key),
+				Message:   fmt.Sprintf("Pipeline %s has no exporters, so its data is not sent anywhere", p.ID),
+			})
+		}
+
+		for _, kind := range []string{RECEIVERS, PROCESSORS, EXPORTERS} {
+			for i, ref := range p.references(kind) {
+				path := fmt.Sprintf("%s[%d]", p.Path(kind), i)
+				node := p.Nodes[kind][i]
+
+				if _, ok := c.components(kind)[ref]; ok {
+					usage[kind][ref] = true
+					checkSignal(report, c, node, path, p, kind, ref)
+					continue
+				}
+				if _, ok := c.Connectors[ref]; ok && kind != PROCESSORS {
+					connectorUsage[kind][ref] = true
+					checkConnectorSignal(report, c, node, path, p, kind, ref)
+					continue
+				}
+				report.Add(utils.Finding{
+					CheckID:   pipelinesCheckID,
+					Severity:  utils.ERRORS,
+					Component: "Collector",
+					Subject:   path,
+					Location:  c.Location(node),
+					Message:   fmt.Sprintf("%s %s is used on pipeline %s, but is not defined on %s", kindName(kind), ref, p.ID, kind),
+				})
+			}
+		}
+	}
+
+	for _, kind := range []string{RECEIVERS, PROCESSORS, EXPORTERS} {
+		for _, id := range sortedKeys(c.components(kind)) {
+			if !usage[kind][id] {
+				comp := c.components(kind)[id]
+				report.Add(utils.Finding{
+					CheckID:   pipelinesCheckID,
+					Severity:  utils.WARNINGS,
+					Component: "Collector",
+					Subject:   comp.Path(),
+					Location:  c.Location(comp.Key),
+					Message:   fmt.Sprintf("%s %s is defined but not used on any pipeline, so it is not enabled", kindName(kind), id),
+				})
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(c.Connectors) {
+		comp := c.Connectors[id]
+		asExporter, asReceiver := connectorUsage[EXPORTERS][id], connectorUsage[RECEIVERS][id]
+		switch {
+		case !asExporter && !asReceiver:
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.WARNINGS,
+				Component: "Collector",
+				Subject:   comp.Path(),
+				Location:  c.Location(comp.Key),
+				Message:   fmt.Sprintf("Connector %s is defined but not used on any pipeline, so it is not enabled", id),
+			})
+		case !asReceiver:
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Collector",
+				Subject:   comp.Path(),
+				Location:  c.Location(comp.Key),
+				Message:   fmt.Sprintf("Connector %s is used as an exporter, but not as a receiver of any pipeline. Connectors must be used on both sides", id),
+			})
+		case !asExporter:
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Collector",
+				Subject:   comp.Path(),
+				Location:  c.Location(comp.Key),
+				Message:   fmt.Sprintf("Connector %s is used as a receiver, but not as an exporter of any pipeline. Connectors must be used on both sides", id),
+			})
+		}
+	}
+
+	for i, id := range c.Service.Extensions {
+		if _, ok := c.Extensions[id]; !ok {
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.ERRORS,
+				Component: "Collector",
+				Subject:   fmt.Sprintf("service.extensions[%d]", i),
+				Location:  c.Location(c.Service.ExtensionNodes[i]),
+				Message:   fmt.Sprintf("Extension %s is enabled on service > extensions, but is not defined on extensions", id),
+			})
+		}
+	}
+	for _, id := range sortedKeys(c.Extensions) {
+		if !slices.Contains(c.Service.Extensions, id) {
+			comp := c.Extensions[id]
+			report.Add(utils.Finding{
+				CheckID:   pipelinesCheckID,
+				Severity:  utils.WARNINGS,
+				Component: "Collector",
+				Subject:   comp.Path(),
+				Location:  c.Location(comp.Key),
+				Message:   fmt.Sprintf("Extension %s is defined but not enabled on service > extensions", id),
+			})
+		}
+	}
+}
+
+func checkSignal(report *utils.Report, c *Config, node *yaml.Node, path string, p *Pipeline, kind string, ref string) {
+	signals, ok := supportedSignals[kind][ParseComponentID(ref).Type]
+	if ok && !slices.Contains(signals, p.ID.Type) {
+		report.Add(utils.Finding{
+			CheckID:   pipelinesCheckID,
+			Severity:  utils.ERRORS,
+			Component: "Collector",
+			Subject:   path,
+			Location:  c.Location(node),
+			Message:   fmt.Sprintf("%s %s only supports %s, but is used on the %s pipeline %s", kindName(kind), ref, joinSignals(signals), p.ID.Type, p.ID),
+		})
+	}
+}
+
+func checkConnectorSignal(report *utils.Report, c *Config, node *yaml.Node, path string, p *Pipeline, kind string, ref string) {
+	signals, ok := connectorSignals[ParseComponentID(ref).Type]
+	if !ok {
+		return
+	}
+	if kind == EXPORTERS && !slices.Contains(signals.consumes, p.ID.Type) {
+		report.Add(utils.Finding{
+			CheckID:   pipelinesCheckID,
+			Severity:  utils.ERRORS,
+			Component: "Collector",
+			Subject:   path,
+			Location:  c.Location(node),
+			Message:   fmt.Sprintf("Connector %s only consumes %s, but is used as an exporter on the %s pipeline %s", ref, joinSignals(signals.consumes), p.ID.Type, p.ID),
+		})
+	}
+	if kind == RECEIVERS && !slices.Contains(signals.produces, p.ID.Type) {
+		report.Add(utils.Finding{
+			CheckID:   pipelinesCheckID,
+			Severity:  utils.ERRORS,
+			Component: "Collector",
+			Subject:   path,
+			Location:  c.Location(node),
+			Message:   fmt.Sprintf("Connector %s only produces %s, but is used as a receiver on the %s pipeline %s", ref, joinSignals(signals.produces), p.ID.Type, p.ID),
+		})
+	}
+}
+
+func (p *Pipeline) references(kind string) []string {
+	switch kind {
+	case RECEIVERS:
+		return p.Receivers
+	case PROCESSORS:
+		return p.Processors
+	case EXPORTERS:
+		return p.Exporters
+	}
+	return nil
+}
+
+// kindName returns the singular name of a kind of component, such as Receiver for receivers.
+func kindName(kind string) string {
+	switch kind {
+	case RECEIVERS:
+		return "Receiver"
+	case PROCESSORS:
+		return "Processor"
+	case EXPORTERS:
+		return "Exporter"
+	case CONNECTORS:
+		return "Connector"
+	case EXTENSIONS:
+		return "Extension"
+	}
+	return kind
+}
+
+func joinSignals(signals []string) string {
+	if len(signals) == 1 {
+		return signals[0]
+	}
+	return strings.Join(signals[:len(signals)-1], ", ") + " and " + signals[len(signals)-1]
+}
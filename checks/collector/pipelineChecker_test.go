@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckPipelineGraph(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "valid",
+			config: `
+receivers:
+  otlp:
+processors:
+  batch:
+exporters:
+  otlphttp:
+connectors:
+  spanmetrics:
+extensions:
+  health_check:
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp, spanmetrics]
+    metrics:
+      receivers: [otlp, spanmetrics]
+      processors: [batch]
+      exporters: [otlphttp]
+`,
+		},
+		{
+			name: "undefined and unused components",
+			config: `
+receivers:
+  otlp:
+  jaeger:
+processors:
+  batch:
+exporters:
+  otlphttp/grafana:
+extensions:
+  pprof:
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [memory_limiter]
+      exporters: [otlphttp]
+`,
+			wantErrors: []string{
+				"service.pipelines.traces.processors[0]",
+				"service.pipelines.traces.exporters[0]",
+				"service.extensions[0]",
+			},
+			wantWarnings: []string{
+				"receivers.jaeger",
+				"processors.batch",
+				"exporters.otlphttp/grafana",
+				"extensions.pprof",
+			},
+		},
+		{
+			name: "empty pipelines",
+			config: `
+receivers:
+  otlp:
+exporters:
+  otlphttp:
+service:
+  pipelines:
+    logs:
+      receivers: [otlp]
+    metrics:
+      exporters: [otlphttp]
+    profiles:
+      receivers: [otlp]
+`,
+			wantErrors: []string{
+				"service.pipelines.logs.exporters",
+				"service.pipelines.metrics.receivers",
+				"service.pipelines.profiles",
+			},
+		},
+		{
+			name: "connectors used on one side",
+			config: `
+receivers:
+  otlp:
+exporters:
+  otlphttp:
+connectors:
+  spanmetrics:
+  forward:
+  count:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlphttp, spanmetrics]
+    logs:
+      receivers: [forward]
+      exporters: [otlphttp]
+`,
+			wantErrors: []string{
+				"connectors.forward",
+				"connectors.spanmetrics",
+			},
+			wantWarnings: []string{
+				"connectors.count",
+			},
+		},
+		{
+			name: "signal mismatches",
+			config: `
+receivers:
+  prometheus:
+  otlp:
+processors:
+  tail_sampling:
+exporters:
+  loki:
+  otlphttp:
+connectors:
+  spanmetrics:
+service:
+  pipelines:
+    traces:
+      receivers: [prometheus, otlp]
+      processors: [tail_sampling]
+      exporters: [loki, spanmetrics]
+    logs:
+      receivers: [spanmetrics]
+      processors: [tail_sampling]
+      exporters: [otlphttp]
+`,
+			wantErrors: []string{
+				"service.pipelines.logs.receivers[0]",
+				"service.pipelines.logs.processors[0]",
+				"service.pipelines.traces.receivers[0]",
+				"service.pipelines.traces.exporters[0]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("config.yaml", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			checkPipelineGraph(report, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			for _, f := range report.Findings {
+				if f.CheckID != pipelinesCheckID || f.Location == nil || f.Location.Line == 0 {
+					t.Errorf("finding %v has no check ID or line", f)
+				}
+			}
+		})
+	}
+}
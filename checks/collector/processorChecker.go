@@ -0,0 +1,330 @@
+package collector
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const processorsCheckID = "collector.processors"
+
+const collectorDocURL = "https://grafana.com/docs/opentelemetry/collector/"
+
+// Actions supported by the attributes of the resource processor.
+var resourceActions = []string{"insert", "update", "upsert", "delete", "hash", "extract", "convert"}
+
+// OTTL conditions of the filter processor, by signal. Data matching any condition is dropped.
+var filterConditions = map[string][][]string{
+	"traces":  {{"traces", "span"}, {"traces", "spanevent"}},
+	"metrics": {{"metrics", "metric"}, {"metrics", "datapoint"}},
+	"logs":    {{"logs", "log_record"}},
+}
+
+// Names matched by the legacy exclude settings of the filter processor, by signal.
+var filterExcludes = map[string][]string{
+	"traces":  {"spans", "exclude", "span_names"},
+	"metrics": {"metrics", "exclude", "metric_names"},
+}
+
+// checkProcessors checks that the pipelines use the processors recommended for
+// production and that the processors they use don't drop all data.
+func checkProcessors(report *utils.Report, c *Config) {
+	for _, id := range sortedKeys(c.Service.Pipelines) {
+		p := c.Service.Pipelines[id]
+		if !slices.Contains(allSignals, p.ID.Type) {
+			continue
+		}
+		checkMemoryLimiterAndBatch(report, c, p)
+
+		for i, ref := range p.Processors {
+			comp, ok := c.Processors[ref]
+			if !ok {
+				continue
+			}
+			node, path := p.Nodes[PROCESSORS][i], fmt.Sprintf("%s[%d]", p.Path(PROCESSORS), i)
+			switch comp.ID.Type {
+			case "tail_sampling":
+				checkTailSampling(report, c, comp, p, node, path)
+			case "filter":
+				checkFilter(report, c, comp, p, node, path)
+			}
+		}
+	}
+
+	for _, comp := range c.ComponentsOfType(PROCESSORS, "memory_limiter") {
+		if !comp.Has("check_interval") {
+			report.Add(utils.Finding{
+				CheckID:     processorsCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     comp.Path("check_interval"),
+				Location:    c.Location(comp.Key),
+				Message:     fmt.Sprintf("Processor %s has no check_interval, so the collector fails to start", comp.ID),
+				Remediation: fmt.Sprintf("Set processors > %s > check_interval, e.g. to 1s", comp.ID),
+				DocURL:      collectorDocURL,
+			})
+		}
+		if !comp.Has("limit_mib") && !comp.Has("limit_percentage") {
+			report.Add(utils.Finding{
+				CheckID:     processorsCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     comp.Path("limit_mib"),
+				Location:    c.Location(comp.Key),
+				Message:     fmt.Sprintf("Processor %s has neither limit_mib nor limit_percentage, so the collector fails to start", comp.ID),
+				Remediation: fmt.Sprintf("Set processors > %s > limit_percentage, e.g. to 80, or limit_mib to the memory available to the collector", comp.ID),
+				DocURL:      collectorDocURL,
+			})
+		}
+	}
+	for _, comp := range c.ComponentsOfType(PROCESSORS, "resourcedetection") {
+		checkResourceDetection(report, c, comp)
+	}
+	for _, comp := range c.ComponentsOfType(PROCESSORS, "resource") {
+		checkResource(report, c, comp)
+	}
+}
+
+// checkMemoryLimiterAndBatch checks that a pipeline starts with memory_limiter and uses batch after it.
+func checkMemoryLimiterAndBatch(report *utils.Report, c *Config, p *Pipeline) {
+	limiter := indexOfType(p.Processors, "memory_limiter")
+	switch {
+	case limiter < 0:
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     p.Path(PROCESSORS),
+			Location:    c.Location(p.Key),
+			Message:     fmt.Sprintf("Pipeline %s has no memory_limiter processor, so the collector can run out of memory when it receives more data than it can export", p.ID),
+			Remediation: fmt.Sprintf("Define a memory_limiter processor and add it as the first processor of service > pipelines > %s > processors", p.ID),
+			DocURL:      collectorDocURL,
+		})
+	case limiter > 0:
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     fmt.Sprintf("%s[%d]", p.Path(PROCESSORS), limiter),
+			Location:    c.Location(p.Nodes[PROCESSORS][limiter]),
+			Message:     fmt.Sprintf("Processor %s is not the first processor of pipeline %s, so the processors before it keep using memory when the limit is reached", p.Processors[limiter], p.ID),
+			Remediation: fmt.Sprintf("Move %s to the start of service > pipelines > %s > processors", p.Processors[limiter], p.ID),
+			DocURL:      collectorDocURL,
+		})
+	}
+
+	batch := indexOfType(p.Processors, "batch")
+	switch {
+	case batch < 0:
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     p.Path(PROCESSORS),
+			Location:    c.Location(p.Key),
+			Message:     fmt.Sprintf("Pipeline %s has no batch processor, so data is exported in many small requests", p.ID),
+			Remediation: fmt.Sprintf("Define a batch processor and add it to service > pipelines > %s > processors, after memory_limiter", p.ID),
+			DocURL:      collectorDocURL,
+		})
+	case batch < limiter:
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     fmt.Sprintf("%s[%d]", p.Path(PROCESSORS), batch),
+			Location:    c.Location(p.Nodes[PROCESSORS][batch]),
+			Message:     fmt.Sprintf("Processor %s is placed before %s on pipeline %s, so data is batched before the memory limit is applied", p.Processors[batch], p.Processors[limiter], p.ID),
+			Remediation: fmt.Sprintf("Move %s after %s on service > pipelines > %s > processors", p.Processors[batch], p.Processors[limiter], p.ID),
+			DocURL:      collectorDocURL,
+		})
+	}
+}
+
+func checkResourceDetection(report *utils.Report, c *Config, comp *Component) {
+	detectors, _, err := stringList(comp.Get("detectors"))
+	if err != nil || len(detectors) == 0 {
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     comp.Path("detectors"),
+			Location:    c.Location(comp.Key),
+			Message:     fmt.Sprintf("Processor %s has no detectors, so it does not add any resource attributes", comp.ID),
+			Remediation: fmt.Sprintf("Set processors > %s > detectors to the platforms the collector runs on, e.g. [env, system]", comp.ID),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	if slices.Contains(detectors, "system") && !comp.Has("system", "hostname_sources") {
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     comp.Path("system", "hostname_sources"),
+			Location:    c.Location(comp.Get("detectors")),
+			Message:     fmt.Sprintf("Processor %s uses the system detector without hostname_sources, so host.name is only looked up with DNS, which is often the name of the container", comp.ID),
+			Remediation: fmt.Sprintf("Set processors > %s > system > hostname_sources, e.g. to [os]", comp.ID),
+			DocURL:      collectorDocURL,
+		})
+	}
+}
+
+func checkResource(report *utils.Report, c *Config, comp *Component) {
+	attributes := comp.Get("attributes")
+	if attributes == nil || attributes.Kind != yaml.SequenceNode || len(attributes.Content) == 0 {
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     comp.Path("attributes"),
+			Location:    c.Location(comp.Key),
+			Message:     fmt.Sprintf("Processor %s has no attributes, so it does not change any resource", comp.ID),
+			Remediation: fmt.Sprintf("Add the resource attributes to change to processors > %s > attributes, or remove the processor", comp.ID),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	for i, attribute := range attributes.Content {
+		path := fmt.Sprintf("%s[%d]", comp.Path("attributes"), i)
+		key, action := scalar(lookup(attribute, "key")), scalar(lookup(attribute, "action"))
+		switch {
+		case key == "":
+			report.Add(utils.Finding{
+				CheckID:     processorsCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     path,
+				Location:    c.Location(attribute),
+				Message:     fmt.Sprintf("Attribute %d of processor %s has no key", i, comp.ID),
+				Remediation: "Set the key of the resource attribute to change",
+				DocURL:      collectorDocURL,
+			})
+		case !slices.Contains(resourceActions, action):
+			report.Add(utils.Finding{
+				CheckID:     processorsCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     path,
+				Location:    c.Location(attribute),
+				Message:     fmt.Sprintf("Attribute %s of processor %s has an unsupported action %q", key, comp.ID, action),
+				Remediation: fmt.Sprintf("Set the action to one of %s", strings.Join(resourceActions, ", ")),
+				DocURL:      collectorDocURL,
+			})
+		case (action == "insert" || action == "update" || action == "upsert") && lookup(attribute, "value") == nil && lookup(attribute, "from_attribute") == nil && lookup(attribute, "from_context") == nil:
+			report.Add(utils.Finding{
+				CheckID:     processorsCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     path,
+				Location:    c.Location(attribute),
+				Message:     fmt.Sprintf("Attribute %s of processor %s uses the %s action without a value", key, comp.ID, action),
+				Remediation: "Set value, from_attribute or from_context on the attribute",
+				DocURL:      collectorDocURL,
+			})
+		}
+	}
+}
+
+func checkTailSampling(report *utils.Report, c *Config, comp *Component, p *Pipeline, node *yaml.Node, path string) {
+	policies := comp.Get("policies")
+	if policies == nil || policies.Kind != yaml.SequenceNode || len(policies.Content) == 0 {
+		report.Add(utils.Finding{
+			CheckID:     processorsCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     path,
+			Location:    c.Location(node),
+			Message:     fmt.Sprintf("Processor %s has no policies, so it drops all traces of pipeline %s", comp.ID, p.ID),
+			Remediation: fmt.Sprintf("Add the policies of the traces to keep to processors > %s > policies", comp.ID),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	for _, policy := range policies.Content {
+		if scalar(lookup(policy, "type")) != "probabilistic" || !isZero(lookup(policy, "probabilistic", "sampling_percentage")) {
+			return
+		}
+	}
+	report.Add(utils.Finding{
+		CheckID:     processorsCheckID,
+		Severity:    utils.ERRORS,
+		Component:   "Collector",
+		Subject:     path,
+		Location:    c.Location(node),
+		Message:     fmt.Sprintf("Processor %s only has probabilistic policies with a sampling_percentage of 0, so it drops all traces of pipeline %s", comp.ID, p.ID),
+		Remediation: "Set a sampling_percentage higher than 0, or add a policy keeping the traces you need",
+		DocURL:      collectorDocURL,
+	})
+}
+
+func checkFilter(report *utils.Report, c *Config, comp *Component, p *Pipeline, node *yaml.Node, path string) {
+	for _, conditionsPath := range filterConditions[p.ID.Type] {
+		conditions, _, err := stringList(comp.Get(conditionsPath...))
+		if err != nil {
+			continue
+		}
+		for _, condition := range conditions {
+			if strings.TrimSpace(condition) == "true" {
+				report.Add(utils.Finding{
+					CheckID:     processorsCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Collector",
+					Subject:     path,
+					Location:    c.Location(node),
+					Message:     fmt.Sprintf("Processor %s has the condition %q on %s, which drops all %s of pipeline %s", comp.ID, condition, strings.Join(conditionsPath, " > "), p.ID.Type, p.ID),
+					Remediation: fmt.Sprintf("Change the conditions of processors > %s to match only the data to drop", comp.ID),
+					DocURL:      collectorDocURL,
+				})
+				return
+			}
+		}
+	}
+
+	excludes, ok := filterExcludes[p.ID.Type]
+	if !ok {
+		return
+	}
+	names, _, err := stringList(comp.Get(excludes...))
+	if err != nil || comp.GetString(append(excludes[:2:2], "match_type")...) != "regexp" {
+		return
+	}
+	for _, name := range names {
+		if name == ".*" || name == "^.*$" {
+			report.Add(utils.Finding{
+				CheckID:     processorsCheckID,
+				Severity:    utils.ERRORS,
+				Component:   "Collector",
+				Subject:     path,
+				Location:    c.Location(node),
+				Message:     fmt.Sprintf("Processor %s excludes names matching %q, which drops all %s of pipeline %s", comp.ID, name, p.ID.Type, p.ID),
+				Remediation: fmt.Sprintf("Change processors > %s > %s to match only the data to drop", comp.ID, strings.Join(excludes, " > ")),
+				DocURL:      collectorDocURL,
+			})
+			return
+		}
+	}
+}
+
+// indexOfType returns the index of the first ID in ids with the given component type, or -1.
+func indexOfType(ids []string, componentType string) int {
+	return slices.IndexFunc(ids, func(id string) bool {
+		return ParseComponentID(id).Type == componentType
+	})
+}
+
+// scalar returns the value of a scalar node, or "" when n is not a scalar.
+func scalar(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+		return ""
+	}
+	return n.Value
+}
+
+func isZero(n *yaml.Node) bool {
+	v := scalar(n)
+	return v == "" || strings.Trim(v, "0.") == ""
+}
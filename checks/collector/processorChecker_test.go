@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckProcessors(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "recommended processors",
+			config: `
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_percentage: 80
+  resourcedetection:
+    detectors: [env, system]
+    system:
+      hostname_sources: [os]
+  resource:
+    attributes:
+      - key: deployment.environment
+        value: production
+        action: upsert
+  batch:
+service:
+  pipelines:
+    traces:
+      processors: [memory_limiter, resourcedetection, resource, batch]
+`,
+		},
+		{
+			name: "missing processors",
+			config: `
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+`,
+			wantWarnings: []string{"service.pipelines.metrics.processors", "service.pipelines.metrics.processors"},
+		},
+		{
+			name: "wrong order",
+			config: `
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_mib: 400
+  batch:
+service:
+  pipelines:
+    logs:
+      processors: [batch, memory_limiter]
+`,
+			wantWarnings: []string{"service.pipelines.logs.processors[1]", "service.pipelines.logs.processors[0]"},
+		},
+		{
+			name: "invalid memory_limiter",
+			config: `
+processors:
+  memory_limiter:
+  batch:
+service:
+  pipelines:
+    logs:
+      processors: [memory_limiter, batch]
+`,
+			wantErrors: []string{"processors.memory_limiter.check_interval", "processors.memory_limiter.limit_mib"},
+		},
+		{
+			name: "resource processors",
+			config: `
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_mib: 400
+  resourcedetection/empty:
+  resourcedetection/system:
+    detectors: [system]
+  resource/empty:
+  resource/invalid:
+    attributes:
+      - action: delete
+      - key: a
+        action: rename
+      - key: b
+        action: insert
+      - key: c
+        action: delete
+  batch:
+service:
+  pipelines:
+    traces:
+      processors: [memory_limiter, resourcedetection/empty, resourcedetection/system, resource/empty, resource/invalid, batch]
+`,
+			wantErrors: []string{
+				"processors.resource/invalid.attributes[0]",
+				"processors.resource/invalid.attributes[1]",
+				"processors.resource/invalid.attributes[2]",
+			},
+			wantWarnings: []string{
+				"processors.resourcedetection/empty.detectors",
+				"processors.resourcedetection/system.system.hostname_sources",
+				"processors.resource/empty.attributes",
+			},
+		},
+		{
+			name: "processors dropping all data",
+			config: `
+processors:
+  memory_limiter:
+    check_interval: 1s
+    limit_mib: 400
+  tail_sampling/none:
+  tail_sampling/zero:
+    policies:
+      - name: nothing
+        type: probabilistic
+        probabilistic:
+          sampling_percentage: 0
+  tail_sampling/errors:
+    policies:
+      - name: errors
+        type: status_code
+        status_code:
+          status_codes: [ERROR]
+  filter/all:
+    error_mode: ignore
+    metrics:
+      metric:
+        - 'name == "unused"'
+        - true
+  filter/legacy:
+    spans:
+      exclude:
+        match_type: regexp
+        span_names: [".*"]
+  filter/health:
+    logs:
+      log_record:
+        - 'attributes["path"] == "/health"'
+  batch:
+service:
+  pipelines:
+    traces:
+      processors: [memory_limiter, tail_sampling/none, tail_sampling/zero, tail_sampling/errors, filter/legacy, batch]
+    metrics:
+      processors: [memory_limiter, filter/all, batch]
+    logs:
+      processors: [memory_limiter, filter/all, filter/health, batch]
+`,
+			wantErrors: []string{
+				"service.pipelines.metrics.processors[1]",
+				"service.pipelines.traces.processors[1]",
+				"service.pipelines.traces.processors[2]",
+				"service.pipelines.traces.processors[4]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("config.yaml", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			checkProcessors(report, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			for _, f := range report.Findings {
+				if f.CheckID != processorsCheckID || f.Remediation == "" || f.DocURL == "" {
+					t.Errorf("finding %v has no check ID, remediation or docs", f)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const receiversCheckID = "collector.receivers"
+
+// Default host of the receivers, since version 0.110.0 of the collector.
+const defaultReceiverHost = "localhost"
+
+type listenerDefault struct {
+	port      string
+	transport string
+}
+
+// Default ports of the protocols of the receivers listening on several endpoints, by type and protocol.
+var receiverProtocols = map[string]map[string]listenerDefault{
+	"otlp": {
+		"grpc": {port: "4317", transport: "tcp"},
+		"http": {port: "4318", transport: "tcp"},
+	},
+	"jaeger": {
+		"grpc":           {port: "14250", transport: "tcp"},
+		"thrift_http":    {port: "14268", transport: "tcp"},
+		"thrift_compact": {port: "6831", transport: "udp"},
+		"thrift_binary":  {port: "6832", transport: "udp"},
+	},
+}
+
+// Default ports of the receivers listening on a single endpoint, by type.
+var receiverEndpoints = map[string]listenerDefault{
+	"zipkin":        {port: "9411", transport: "tcp"},
+	"opencensus":    {port: "55678", transport: "tcp"},
+	"signalfx":      {port: "9943", transport: "tcp"},
+	"splunk_hec":    {port: "8088", transport: "tcp"},
+	"influxdb":      {port: "8086", transport: "tcp"},
+	"fluentforward": {port: "8006", transport: "tcp"},
+	"carbon":        {port: "2003", transport: "tcp"},
+	"statsd":        {port: "8125", transport: "udp"},
+}
+
+// listener is an address a receiver binds to.
+type listener struct {
+	receiver  *Component
+	protocol  string
+	transport string
+	host      string
+	port      string
+	node      *yaml.Node
+	path      string
+	// unresolved is set when the endpoint contains a reference, such as
+	// ${env:MY_POD_IP}, that could not be expanded and is reported as such.
+	// The host, and the port when it is part of the reference, are then unknown.
+	unresolved bool
+}
+
+func (l listener) address() string {
+	return net.JoinHostPort(l.host, l.port)
+}
+
+// name describes the listener, such as "protocol grpc of receiver otlp".
+func (l listener) name() string {
+	if l.protocol == "" {
+		return "receiver " + l.receiver.ID.String()
+	}
+	return fmt.Sprintf("protocol %s of receiver %s", l.protocol, l.receiver.ID)
+}
+
+// checkReceiverEndpoints checks the addresses the receivers used on pipelines
+// bind to, and that the application exports to one of them.
+func checkReceiverEndpoints(report *utils.Report, env utils.Env, c *Config) {
+	used := map[string]bool{}
+	for _, p := range c.Service.Pipelines {
+		for _, id := range p.Receivers {
+			used[id] = true
+		}
+	}
+
+	var listeners []listener
+	for _, id := range sortedKeys(c.Receivers) {
+		if used[id] {
+			listeners = append(listeners, receiverListeners(report, c, c.Receivers[id])...)
+		}
+	}
+
+	for i, l := range listeners {
+		if l.unresolved {
+			continue
+		}
+		if isAllInterfaces(l.host) {
+			report.Add(utils.Finding{
+				CheckID:     receiversCheckID,
+				Severity:    utils.WARNINGS,
+				Component:   "Collector",
+				Subject:     l.path,
+				Location:    c.Location(l.node),
+				Message:     fmt.Sprintf("The %s listens on %s, so it accepts data from every network the collector is connected to", l.name(), l.address()),
+				Remediation: "Bind the receiver to localhost when the applications run on the same host, or to the IP of the pod or container, e.g. ${env:MY_POD_IP}",
+				DocURL:      collectorDocURL,
+			})
+		}
+		for _, other := range listeners[:i] {
+			if !other.unresolved && other.port == l.port && other.transport == l.transport && hostsOverlap(other.host, l.host) {
+				report.Add(utils.Finding{
+					CheckID:     receiversCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Collector",
+					Subject:     l.path,
+					Location:    c.Location(l.node),
+					Message:     fmt.Sprintf("The %s listens on %s, which is already used by the %s, so the collector fails to start", l.name(), l.address(), other.name()),
+					Remediation: fmt.Sprintf("Set a different port on %s", l.path),
+					DocURL:      collectorDocURL,
+				})
+			}
+		}
+	}
+
+	checkApplicationEndpoint(report, env, c, listeners)
+}
+
+// receiverListeners returns the addresses a receiver binds to, reporting the endpoints that are not valid.
+func receiverListeners(report *utils.Report, c *Config, r *Component) []listener {
+	var listeners []listener
+	add := func(protocol string, defaults listenerDefault, path ...string) {
+		l := listener{receiver: r, protocol: protocol, transport: defaults.transport, host: defaultReceiverHost, port: defaults.port, node: r.Key, path: r.Path(path...)}
+		if n := r.Get(append(path, "endpoint")...); n != nil {
+			l.node, l.path = n, r.Path(append(path, "endpoint")...)
+			endpoint := r.GetString(append(path, "endpoint")...)
+			if strings.Contains(endpoint, "${") {
+				l.unresolved, l.host, l.port = true, endpoint, ""
+				if i := strings.LastIndex(endpoint, ":"); i >= 0 && !strings.ContainsAny(endpoint[i+1:], "${}") {
+					l.host, l.port = endpoint[:i], endpoint[i+1:]
+				}
+				listeners = append(listeners, l)
+				return
+			}
+			host, port, err := net.SplitHostPort(endpoint)
+			if err != nil {
+				report.Add(utils.Finding{
+					CheckID:     receiversCheckID,
+					Severity:    utils.ERRORS,
+					Component:   "Collector",
+					Subject:     l.path,
+					Location:    c.Location(n),
+					Message:     fmt.Sprintf("The %s has an invalid endpoint: %s", l.name(), err),
+					Remediation: "Set the endpoint in the format host:port, e.g. localhost:" + defaults.port,
+					DocURL:      collectorDocURL,
+				})
+				return
+			}
+			l.host, l.port = host, port
+		} else if n := r.Get(path...); n != nil {
+			l.node = n
+		}
+		listeners = append(listeners, l)
+	}
+
+	if protocols, ok := receiverProtocols[r.ID.Type]; ok {
+		for _, protocol := range sortedKeys(protocols) {
+			if r.Has("protocols", protocol) {
+				add(protocol, protocols[protocol], "protocols", protocol)
+			}
+		}
+	} else if defaults, ok := receiverEndpoints[r.ID.Type]; ok {
+		add("", defaults)
+	}
+	return listeners
+}
+
+// checkApplicationEndpoint checks that OTEL_EXPORTER_OTLP_ENDPOINT targets an OTLP receiver of the collector, with its protocol.
+func checkApplicationEndpoint(report *utils.Report, env utils.Env, c *Config, listeners []listener) {
+	endpoint := env.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" || strings.Contains(endpoint, "grafana.net") {
+		return
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	protocol := env.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	if protocol == "" {
+		protocol = "http/protobuf"
+	}
+	want := "http"
+	if protocol == "grpc" {
+		want = "grpc"
+	}
+
+	var otlp []listener
+	for _, l := range listeners {
+		if l.receiver.ID.Type == "otlp" {
+			otlp = append(otlp, l)
+		}
+	}
+	if len(otlp) == 0 {
+		return
+	}
+
+	i := slices.IndexFunc(otlp, func(l listener) bool { return l.port == port })
+	if i < 0 && slices.ContainsFunc(otlp, func(l listener) bool { return l.port == "" }) {
+		// The port of an unresolved endpoint could be the one of the application.
+		return
+	}
+	if i < 0 {
+		addresses := make([]string, 0, len(otlp))
+		for _, l := range otlp {
+			addresses = append(addresses, fmt.Sprintf("%s (%s)", l.address(), l.protocol))
+		}
+		report.Add(utils.Finding{
+			CheckID:     receiversCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     "OTEL_EXPORTER_OTLP_ENDPOINT",
+			Location:    c.Location(nil),
+			Message:     fmt.Sprintf("OTEL_EXPORTER_OTLP_ENDPOINT is %s, but no OTLP receiver of the collector listens on port %s. The OTLP receivers listen on %s", endpoint, port, strings.Join(addresses, ", ")),
+			Remediation: "Set OTEL_EXPORTER_OTLP_ENDPOINT to the address of an OTLP receiver of the collector",
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+
+	l := otlp[i]
+	if l.protocol != want {
+		report.Add(utils.Finding{
+			CheckID:     receiversCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     "OTEL_EXPORTER_OTLP_ENDPOINT",
+			Location:    c.Location(l.node),
+			Message:     fmt.Sprintf("OTEL_EXPORTER_OTLP_ENDPOINT targets port %s, which is the %s endpoint of receiver %s, but OTEL_EXPORTER_OTLP_PROTOCOL is %s", port, l.protocol, l.receiver.ID, protocol),
+			Remediation: fmt.Sprintf("Use the port of the %s protocol of the receiver, or change OTEL_EXPORTER_OTLP_PROTOCOL", want),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	if isLocalhost(l.host) && !isLocalhost(u.Hostname()) {
+		report.Add(utils.Finding{
+			CheckID:     receiversCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     "OTEL_EXPORTER_OTLP_ENDPOINT",
+			Location:    c.Location(l.node),
+			Message:     fmt.Sprintf("OTEL_EXPORTER_OTLP_ENDPOINT targets the host %s, but the %s only listens on %s, so it can only be reached from the host of the collector", u.Hostname(), l.name(), l.address()),
+			Remediation: fmt.Sprintf("Set the endpoint of the receiver to an address reachable from the application, e.g. 0.0.0.0:%s", l.port),
+			DocURL:      collectorDocURL,
+		})
+		return
+	}
+	report.Add(utils.Finding{
+		CheckID:   receiversCheckID,
+		Severity:  utils.CHECKS,
+		Component: "Collector",
+		Subject:   "OTEL_EXPORTER_OTLP_ENDPOINT",
+		Location:  c.Location(l.node),
+		Message:   fmt.Sprintf("OTEL_EXPORTER_OTLP_ENDPOINT targets the %s endpoint of receiver %s", l.protocol, l.receiver.ID),
+	})
+}
+
+func isAllInterfaces(host string) bool {
+	return host == "" || host == "0.0.0.0" || host == "::"
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "::1" || strings.HasPrefix(host, "127.")
+}
+
+// hostsOverlap returns whether binding both hosts on the same port conflicts.
+func hostsOverlap(a string, b string) bool {
+	return a == b || isAllInterfaces(a) || isAllInterfaces(b)
+}
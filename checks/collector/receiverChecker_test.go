@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckReceiverEndpoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		env          map[string]string
+		wantErrors   []string
+		wantWarnings []string
+		wantChecks   []string
+	}{
+		{
+			name: "default endpoints",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+			env:        map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"},
+			wantChecks: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		{
+			name: "conflicting and invalid endpoints",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+      http:
+        endpoint: localhost
+  otlp/second:
+    protocols:
+      http:
+        endpoint: localhost:4317
+  zipkin:
+    endpoint: :9411
+  jaeger:
+    protocols:
+      thrift_compact:
+        endpoint: localhost:4317
+  otlp/unused:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+service:
+  pipelines:
+    traces:
+      receivers: [otlp, otlp/second, zipkin, jaeger]
+`,
+			wantErrors: []string{
+				"receivers.otlp.protocols.http.endpoint",
+				"receivers.otlp/second.protocols.http.endpoint",
+			},
+			wantWarnings: []string{
+				"receivers.otlp.protocols.grpc.endpoint",
+				"receivers.zipkin.endpoint",
+			},
+		},
+		{
+			name: "application using the wrong protocol",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+			env:        map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4317"},
+			wantErrors: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		{
+			name: "application using a port without receivers",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+			env:        map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+			wantErrors: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		{
+			name: "application on another host",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 127.0.0.1:4317
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+			env:          map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317", "OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+			wantWarnings: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		{
+			name: "unresolved endpoints",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: ${env:MY_POD_IP}:4317
+      http:
+        endpoint: ${env:OTLP_HTTP_ENDPOINT}
+  otlp/second:
+    protocols:
+      grpc:
+        endpoint: ${env:MY_POD_IP}:4317
+service:
+  pipelines:
+    traces:
+      receivers: [otlp, otlp/second]
+`,
+			env:        map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4317", "OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+			wantChecks: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		{
+			name: "application sending to Grafana Cloud",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+`,
+			env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp-gateway-prod-us-east-0.grafana.net/otlp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("config.yaml", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(tt.env)}
+			checkReceiverEndpoints(report, env, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			if got := utilstest.Subjects(report, utils.CHECKS); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceError is returned by LoadConfig when one of the sources can't be read or parsed.
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// UnresolvedReference is a ${...} reference of the config that could not be expanded.
+type UnresolvedReference struct {
+	// Reference is the reference as written on the config, such as ${env:API_KEY}.
+	Reference string
+	// Path is the YAML path of the value containing the reference.
+	Path string
+	Node *yaml.Node
+	Err  error
+}
+
+// LoadConfig loads a config from sources the same way as the collector does
+// when it is started with a "--config" flag for each of them: maps are merged
+// in order, with the values of the last sources taking precedence, lists are
+// replaced, and ${...} references are expanded after merging.
+//
+// Sources are files, or URIs of the file:, env:, yaml:, http: and https: providers.
+// References that can't be expanded are returned on Config.Unresolved.
+func LoadConfig(env utils.Env, sources []string) (*Config, error) {
+	return loadConfig(env, sources, true)
+}
+
+// MergeConfig loads a config from sources like LoadConfig, without expanding
+// the ${...} references, for example to keep them when converting the config.
+func MergeConfig(env utils.Env, sources []string) (*Config, error) {
+	return loadConfig(env, sources, false)
+}
+
+func loadConfig(env utils.Env, sources []string, expand bool) (*Config, error) {
+	files := map[*yaml.Node]string{}
+	var root *yaml.Node
+	for _, source := range sources {
+		data, err := retrieve(env, source)
+		if err != nil {
+			return nil, &SourceError{Source: source, Err: err}
+		}
+		n, err := parseSource(sourceName(source), data, files)
+		if err != nil {
+			return nil, &SourceError{Source: source, Err: err}
+		}
+		if strings.HasPrefix(source, "yaml:") {
+			n = nestKeys(n)
+		}
+		root = mergeNodes(root, n)
+	}
+
+	var unresolved []UnresolvedReference
+	if expand {
+		expandNode(env, root, "", files, &unresolved)
+	}
+
+	c, err := newConfig(sources, root, files)
+	if err != nil {
+		return nil, err
+	}
+	c.Unresolved = unresolved
+	return c, nil
+}
+
+// retrieve returns the YAML content of a source.
+func retrieve(env utils.Env, source string) ([]byte, error) {
+	if file, ok := utils.ConfigFile(source); ok {
+		return env.ReadFile(file)
+	}
+	scheme, value, _ := strings.Cut(source, ":")
+	switch scheme {
+	case "env":
+		content := env.Getenv(value)
+		if content == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", value)
+		}
+		return []byte(content), nil
+	case "yaml":
+		return []byte(value), nil
+	case "http", "https":
+		return fetch(env, source)
+	}
+	return nil, fmt.Errorf("config provider %s is not supported. Possible values: file, env, yaml, http, https", scheme)
+}
+
+// Time the http and https sources have to respond, so that a server that never
+// responds doesn't block the checks.
+var fetchTimeout = 10 * time.Second
+
+func fetch(env utils.Env, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(env.Context, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := env.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// sourceName returns the name used in the locations of the nodes of a source,
+// which is the path of the file for the sources read from files.
+func sourceName(source string) string {
+	if file, ok := utils.ConfigFile(source); ok {
+		return file
+	}
+	return source
+}
+
+// parseSource parses the content of a source, recording it as the source of every node.
+// It returns nil when the source is empty.
+func parseSource(name string, data []byte, files map[*yaml.Node]string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	markSource(root, name, files)
+	return root, nil
+}
+
+func markSource(n *yaml.Node, name string, files map[*yaml.Node]string) {
+	files[n] = name
+	for _, child := range n.Content {
+		markSource(child, name, files)
+	}
+}
+
+// mergeNodes merges src into dst. Maps are merged key by key, while any other
+// value of src replaces the one of dst.
+func mergeNodes(dst *yaml.Node, src *yaml.Node) *yaml.Node {
+	if dst == nil {
+		return src
+	}
+	if src == nil {
+		return dst
+	}
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		merged := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				dst.Content[j+1] = mergeNodes(dst.Content[j+1], value)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+	return dst
+}
+
+// nestKeys expands the keys of the yaml: provider, such as
+// "exporters::otlphttp::endpoint", into nested maps.
+func nestKeys(n *yaml.Node) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return n
+	}
+	nested := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: n.Line}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], nestKeys(n.Content[i+1])
+		parts := strings.Split(key.Value, "::")
+		for j := len(parts) - 1; j > 0; j-- {
+			k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[j], Line: key.Line}
+			value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: key.Line, Content: []*yaml.Node{k, value}}
+		}
+		k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[0], Line: key.Line}
+		nested = mergeNodes(nested, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{k, value}})
+	}
+	return nested
+}
+
+// references matches the escaped "$$" and the ${...} references of a value.
+var references = regexp.MustCompile(`\$\$|\$\{([^${}]+)\}`)
+
+// expandNode expands the ${...} references of every scalar value below n.
+func expandNode(env utils.Env, n *yaml.Node, path string, files map[*yaml.Node]string, unresolved *[]UnresolvedReference) {
+	if n == nil {
+		return
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			p := n.Content[i].Value
+			if path != "" {
+				p = path + "." + p
+			}
+			expandNode(env, n.Content[i+1], p, files, unresolved)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			expandNode(env, item, fmt.Sprintf("%s[%d]", path, i), files, unresolved)
+		}
+	case yaml.ScalarNode:
+		expandScalar(env, n, path, files, unresolved)
+	}
+}
+
+func expandScalar(env utils.Env, n *yaml.Node, path string, files map[*yaml.Node]string, unresolved *[]UnresolvedReference) {
+	matches := references.FindAllStringSubmatchIndex(n.Value, -1)
+	if len(matches) == 0 {
+		return
+	}
+
+	// A value made of a single reference is replaced by the YAML it resolves to,
+	// so it can also be a map or a list.
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(n.Value) && matches[0][2] >= 0 {
+		value, err := resolve(env, n.Value[2:len(n.Value)-1])
+		if err != nil {
+			*unresolved = append(*unresolved, UnresolvedReference{Reference: n.Value, Path: path, Node: n, Err: err})
+			return
+		}
+		var doc yaml.Node
+		if yaml.Unmarshal([]byte(value), &doc) == nil && len(doc.Content) > 0 {
+			if v := doc.Content[0]; v.Kind == yaml.MappingNode || v.Kind == yaml.SequenceNode {
+				markLine(v, n.Line, files[n], files)
+				*n = *v
+				return
+			}
+		}
+		n.Value, n.Tag = strings.TrimRight(value, "\n"), "!!str"
+		return
+	}
+
+	var expanded strings.Builder
+	last := 0
+	for _, m := range matches {
+		expanded.WriteString(n.Value[last:m[0]])
+		last = m[1]
+		if m[2] < 0 {
+			expanded.WriteString("$")
+			continue
+		}
+		value, err := resolve(env, n.Value[m[2]:m[3]])
+		if err != nil {
+			*unresolved = append(*unresolved, UnresolvedReference{Reference: n.Value[m[0]:m[1]], Path: path, Node: n, Err: err})
+			expanded.WriteString(n.Value[m[0]:m[1]])
+			continue
+		}
+		expanded.WriteString(strings.TrimRight(value, "\n"))
+	}
+	expanded.WriteString(n.Value[last:])
+	n.Value, n.Tag = expanded.String(), "!!str"
+}
+
+// markLine sets the line and source of the nodes a reference was expanded into
+// to the ones of the reference.
+func markLine(n *yaml.Node, line int, name string, files map[*yaml.Node]string) {
+	n.Line = line
+	files[n] = name
+	for _, child := range n.Content {
+		markLine(child, line, name, files)
+	}
+}
+
+// resolve returns the value of the content of a ${...} reference, such as
+// env:API_KEY, env:API_KEY:-default, API_KEY or file:token.txt.
+func resolve(env utils.Env, ref string) (string, error) {
+	scheme, value, ok := strings.Cut(ref, ":")
+	if !ok {
+		scheme, value = "env", ref
+	}
+	switch scheme {
+	case "env":
+		name, def, hasDefault := strings.Cut(value, ":-")
+		if v := env.Getenv(name); v != "" {
+			return v, nil
+		}
+		if hasDefault {
+			return def, nil
+		}
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	case "file":
+		data, err := env.ReadFile(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case "yaml":
+		return value, nil
+	}
+	return "", fmt.Errorf("config provider %s is not supported. Possible values: env, file, yaml", scheme)
+}
@@ -0,0 +1,168 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"otel-checker/checks/utils"
+)
+
+func TestLoadConfig(t *testing.T) {
+	env := utils.Env{
+		Context: context.Background(),
+		Getenv: utils.MapGetenv(map[string]string{
+			"GRAFANA_ENDPOINT": "https://otlp-gateway-prod-us-east-0.grafana.net/otlp",
+			"EXTRA_CONFIG":     "exporters:\n  debug:\n    verbosity: detailed\n",
+		}),
+		FS: fstest.MapFS{
+			"base.yaml": {Data: []byte(`
+receivers:
+  otlp:
+    protocols:
+      grpc:
+exporters:
+  otlphttp:
+    endpoint: ${env:GRAFANA_ENDPOINT}
+    headers:
+      Authorization: Basic ${env:GRAFANA_TOKEN}
+      X-Scope: ${env:SCOPE:-default}
+      X-Price: $${literal}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlphttp]
+`)},
+			"override.yaml": {Data: []byte(`
+receivers:
+  otlp:
+    protocols:
+      http:
+service:
+  pipelines:
+    traces:
+      exporters: [otlphttp, debug]
+`)},
+		},
+	}
+
+	c, err := LoadConfig(env, []string{"base.yaml", "file:override.yaml", "env:EXTRA_CONFIG", "yaml:processors::batch::timeout: 2s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	otlp := c.Receivers["otlp"]
+	if !otlp.Has("protocols", "grpc") || !otlp.Has("protocols", "http") {
+		t.Errorf("receiver maps were not merged")
+	}
+	if got := c.Exporters["debug"].GetString("verbosity"); got != "detailed" {
+		t.Errorf("debug verbosity = %q", got)
+	}
+	if got := c.Processors["batch"].GetString("timeout"); got != "2s" {
+		t.Errorf("batch timeout = %q", got)
+	}
+	if got := c.Service.Pipelines["traces"]; !slices.Equal(got.Exporters, []string{"otlphttp", "debug"}) || !slices.Equal(got.Receivers, []string{"otlp"}) {
+		t.Errorf("pipeline = %+v", got)
+	}
+	if loc := c.Location(c.Service.Pipelines["traces"].Nodes[EXPORTERS][0]); loc.File != "override.yaml" || loc.Line != 9 {
+		t.Errorf("exporters location = %v", loc)
+	}
+
+	exporter := c.Exporters["otlphttp"]
+	if got := exporter.GetString("endpoint"); got != "https://otlp-gateway-prod-us-east-0.grafana.net/otlp" {
+		t.Errorf("endpoint = %q", got)
+	}
+	if got := exporter.GetString("headers", "X-Scope"); got != "default" {
+		t.Errorf("X-Scope = %q", got)
+	}
+	if got := exporter.GetString("headers", "X-Price"); got != "${literal}" {
+		t.Errorf("X-Price = %q", got)
+	}
+
+	if len(c.Unresolved) != 1 {
+		t.Fatalf("unresolved = %+v", c.Unresolved)
+	}
+	ref := c.Unresolved[0]
+	if ref.Reference != "${env:GRAFANA_TOKEN}" || ref.Path != "exporters.otlphttp.headers.Authorization" || c.Location(ref.Node).String() != "base.yaml:10" {
+		t.Errorf("unresolved = %+v", ref)
+	}
+}
+
+func TestLoadConfigExpandsMaps(t *testing.T) {
+	env := utils.Env{
+		Context: context.Background(),
+		Getenv:  utils.MapGetenv(nil),
+		FS: fstest.MapFS{
+			"config.yaml":    {Data: []byte("exporters: ${file:exporters.yaml}\n")},
+			"exporters.yaml": {Data: []byte("otlphttp:\n  endpoint: http://localhost:4318\n")},
+		},
+	}
+	c, err := LoadConfig(env, []string{"config.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e, ok := c.Exporters["otlphttp"]
+	if !ok || e.GetString("endpoint") != "http://localhost:4318" || c.Location(e.Key).String() != "config.yaml:1" {
+		t.Errorf("exporters = %+v", c.Exporters)
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	env := utils.Env{
+		Context: context.Background(),
+		Getenv:  utils.MapGetenv(map[string]string{"ENDPOINT": "http://localhost:4318"}),
+		FS:      fstest.MapFS{"config.yaml": {Data: []byte("exporters:\n  otlphttp:\n    endpoint: ${env:ENDPOINT}\n")}},
+	}
+	c, err := MergeConfig(env, []string{"config.yaml", "yaml:exporters::otlphttp::compression: none"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := c.Exporters["otlphttp"]
+	if e.GetString("endpoint") != "${env:ENDPOINT}" || e.GetString("compression") != "none" {
+		t.Errorf("exporters = %+v", c.Exporters)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(nil), FS: fstest.MapFS{}}
+	for _, source := range []string{"missing.yaml", "env:MISSING", "s3:bucket/config.yaml", "yaml:receivers: ["} {
+		_, err := LoadConfig(env, []string{source})
+		var sourceErr *SourceError
+		if !errors.As(err, &sourceErr) || sourceErr.Source != source {
+			t.Errorf("error loading %s = %v, want a SourceError", source, err)
+		}
+	}
+}
+
+func TestLoadConfigFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.yaml" {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("exporters:\n  debug:\n"))
+	}))
+	defer server.Close()
+	defer func(timeout time.Duration) { fetchTimeout = timeout }(fetchTimeout)
+	fetchTimeout = 50 * time.Millisecond
+
+	env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(nil), HTTPClient: server.Client()}
+	c, err := LoadConfig(env, []string{server.URL + "/config.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := c.Exporters["debug"]; !ok {
+		t.Errorf("exporters = %+v", c.Exporters)
+	}
+
+	_, err = LoadConfig(env, []string{server.URL + "/slow.yaml"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error loading a source that never responds = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"otel-checker/checks/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+const telemetryCheckID = "collector.telemetry"
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+var metricsLevels = []string{"none", "basic", "normal", "detailed"}
+
+// Port the collector exposes its own metrics on by default.
+const internalMetricsPort = "8888"
+
+// Extensions recommended to operate the collector, with the impact of not enabling them.
+var operationalExtensions = []struct {
+	componentType string
+	impact        string
+}{
+	{"health_check", "so orchestrators and load balancers can't tell whether the collector is healthy, e.g. with Kubernetes liveness and readiness probes"},
+	{"pprof", "so the CPU and memory usage of the collector can't be profiled when it uses more resources than expected"},
+	{"zpages", "so the pipelines and the spans of the collector can't be inspected live when troubleshooting data that is not received"},
+}
+
+// checkTelemetry checks the internal telemetry of the collector and the
+// extensions used to operate it.
+func checkTelemetry(report *utils.Report, c *Config) {
+	logs := lookup(c.Service.Telemetry, "logs")
+	level := strings.ToLower(scalar(lookup(logs, "level")))
+	switch {
+	case level == "":
+	case !slices.Contains(logLevels, level):
+		report.Add(utils.Finding{
+			CheckID:     telemetryCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     "service.telemetry.logs.level",
+			Location:    c.Location(lookup(logs, "level")),
+			Message:     fmt.Sprintf("The log level of the collector is %s, which is not supported, so the collector fails to start", level),
+			Remediation: fmt.Sprintf("Set service > telemetry > logs > level to one of %s", strings.Join(logLevels, ", ")),
+			DocURL:      collectorDocURL,
+		})
+	case level == "debug":
+		report.Add(utils.Finding{
+			CheckID:     telemetryCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     "service.telemetry.logs.level",
+			Location:    c.Location(lookup(logs, "level")),
+			Message:     "The log level of the collector is debug, which logs for every batch of data and increases the CPU usage and log volume of the collector",
+			Remediation: "Set service > telemetry > logs > level to info, and only use debug while troubleshooting",
+			DocURL:      collectorDocURL,
+		})
+	}
+
+	metrics := lookup(c.Service.Telemetry, "metrics")
+	metricsLevel := strings.ToLower(scalar(lookup(metrics, "level")))
+	switch {
+	case metricsLevel == "":
+	case !slices.Contains(metricsLevels, metricsLevel):
+		report.Add(utils.Finding{
+			CheckID:     telemetryCheckID,
+			Severity:    utils.ERRORS,
+			Component:   "Collector",
+			Subject:     "service.telemetry.metrics.level",
+			Location:    c.Location(lookup(metrics, "level")),
+			Message:     fmt.Sprintf("The metrics level of the collector is %s, which is not supported, so the collector fails to start", metricsLevel),
+			Remediation: fmt.Sprintf("Set service > telemetry > metrics > level to one of %s", strings.Join(metricsLevels, ", ")),
+			DocURL:      collectorDocURL,
+		})
+	case metricsLevel == "none":
+		report.Add(utils.Finding{
+			CheckID:     telemetryCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     "service.telemetry.metrics.level",
+			Location:    c.Location(lookup(metrics, "level")),
+			Message:     "The metrics level of the collector is none, so there are no metrics to notice refused or dropped data, full queues or failed exports",
+			Remediation: "Set service > telemetry > metrics > level to normal",
+			DocURL:      collectorDocURL,
+		})
+	}
+	if address := lookup(metrics, "address"); address != nil {
+		report.Add(utils.Finding{
+			CheckID:     telemetryCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     "service.telemetry.metrics.address",
+			Location:    c.Location(address),
+			Message:     "service > telemetry > metrics > address is deprecated, and is ignored by recent versions of the collector",
+			Remediation: fmt.Sprintf("Replace it with a pull reader on service > telemetry > metrics > readers, with a prometheus exporter on port %s", internalMetricsPort),
+			DocURL:      collectorDocURL,
+		})
+	}
+	if metricsLevel != "none" && !internalMetricsExported(c, metrics) {
+		report.Add(utils.Finding{
+			CheckID:     telemetryCheckID,
+			Severity:    utils.WARNINGS,
+			Component:   "Collector",
+			Subject:     "service.telemetry.metrics.readers",
+			Location:    c.Location(c.Service.Key),
+			Message:     "The metrics of the collector itself are not exported anywhere, so refused or dropped data, full queues and failed exports go unnoticed",
+			Remediation: fmt.Sprintf("Add a periodic reader with an otlp exporter on service > telemetry > metrics > readers, or scrape port %s with a prometheus receiver used on a metrics pipeline", internalMetricsPort),
+			DocURL:      collectorDocURL,
+		})
+	}
+
+	for _, ext := range operationalExtensions {
+		checkOperationalExtension(report, c, ext.componentType, ext.impact)
+	}
+}
+
+// internalMetricsExported returns whether the metrics of the collector are pushed by a
+// periodic reader, or scraped by a prometheus receiver used on a metrics pipeline.
+func internalMetricsExported(c *Config, metrics *yaml.Node) bool {
+	port := internalMetricsPort
+	if readers := lookup(metrics, "readers"); readers != nil {
+		for _, reader := range readers.Content {
+			if lookup(reader, "periodic") != nil {
+				return true
+			}
+			if p := scalar(lookup(reader, "pull", "exporter", "prometheus", "port")); p != "" {
+				port = p
+			}
+		}
+	} else if address := scalar(lookup(metrics, "address")); address != "" {
+		if _, p, err := net.SplitHostPort(address); err == nil {
+			port = p
+		}
+	}
+
+	for _, p := range c.PipelinesOfSignal("metrics") {
+		for _, id := range p.Receivers {
+			r, ok := c.Receivers[id]
+			if !ok || r.ID.Type != "prometheus" {
+				continue
+			}
+			if scrapesPort(r.Get("config", "scrape_configs"), port) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scrapesPort returns whether a static target of the scrape configs uses port.
+func scrapesPort(n *yaml.Node, port string) bool {
+	if n == nil {
+		return false
+	}
+	if n.Kind == yaml.ScalarNode {
+		return strings.HasSuffix(n.Value, ":"+port)
+	}
+	for _, child := range n.Content {
+		if scrapesPort(child, port) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkOperationalExtension(report *utils.Report, c *Config, componentType string, impact string) {
+	for _, ext := range c.ComponentsOfType(EXTENSIONS, componentType) {
+		if !slices.Contains(c.Service.Extensions, ext.ID.String()) {
+			continue
+		}
+		host, _, err := net.SplitHostPort(ext.GetString("endpoint"))
+		if err == nil && isAllInterfaces(host) {
+			report.Add(utils.Finding{
+				CheckID:     telemetryCheckID,
+				Severity:    utils.WARNINGS,
+				Component:   "Collector",
+				Subject:     ext.Path("endpoint"),
+				Location:    c.Location(ext.Get("endpoint")),
+				Message:     fmt.Sprintf("Extension %s listens on all network interfaces, so anyone who can reach the collector can access it", ext.ID),
+				Remediation: fmt.Sprintf("Set extensions > %s > endpoint to localhost, or to the IP of the pod when it is used by probes", ext.ID),
+				DocURL:      collectorDocURL,
+			})
+		} else {
+			report.Add(utils.Finding{
+				CheckID:   telemetryCheckID,
+				Severity:  utils.CHECKS,
+				Component: "Collector",
+				Subject:   ext.Path(),
+				Location:  c.Location(ext.Key),
+				Message:   fmt.Sprintf("Extension %s is enabled", ext.ID),
+			})
+		}
+		return
+	}
+	report.Add(utils.Finding{
+		CheckID:     telemetryCheckID,
+		Severity:    utils.WARNINGS,
+		Component:   "Collector",
+		Subject:     "service.extensions",
+		Location:    c.Location(c.Service.Key),
+		Message:     fmt.Sprintf("No %s extension is enabled, %s", componentType, impact),
+		Remediation: fmt.Sprintf("Define a %s extension and add it to service > extensions", componentType),
+		DocURL:      collectorDocURL,
+	})
+}
@@ -0,0 +1,131 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckTelemetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantErrors   []string
+		wantWarnings []string
+		wantChecks   []string
+	}{
+		{
+			name: "recommended",
+			config: `
+extensions:
+  health_check:
+    endpoint: ${env:MY_POD_IP}:13133
+  pprof:
+  zpages:
+    endpoint: localhost:55679
+service:
+  extensions: [health_check, pprof, zpages]
+  telemetry:
+    logs:
+      level: info
+    metrics:
+      level: detailed
+      readers:
+        - periodic:
+            exporter:
+              otlp:
+                protocol: http/protobuf
+                endpoint: http://localhost:4318
+`,
+			wantChecks: []string{"extensions.health_check", "extensions.pprof", "extensions.zpages"},
+		},
+		{
+			name: "nothing configured",
+			config: `
+service:
+  pipelines:
+`,
+			wantWarnings: []string{
+				"service.telemetry.metrics.readers",
+				"service.extensions",
+				"service.extensions",
+				"service.extensions",
+			},
+		},
+		{
+			name: "verbose logs and metrics scraped by prometheus",
+			config: `
+receivers:
+  prometheus:
+    config:
+      scrape_configs:
+        - job_name: otelcol
+          static_configs:
+            - targets: [localhost:9999]
+extensions:
+  health_check:
+    endpoint: 0.0.0.0:13133
+  pprof:
+  zpages:
+service:
+  extensions: [health_check, zpages]
+  telemetry:
+    logs:
+      level: DEBUG
+    metrics:
+      address: localhost:9999
+  pipelines:
+    metrics:
+      receivers: [prometheus]
+`,
+			wantWarnings: []string{
+				"service.telemetry.logs.level",
+				"service.telemetry.metrics.address",
+				"extensions.health_check.endpoint",
+				"service.extensions",
+			},
+			wantChecks: []string{"extensions.zpages"},
+		},
+		{
+			name: "invalid and disabled telemetry",
+			config: `
+extensions:
+  health_check:
+  pprof:
+  zpages:
+service:
+  extensions: [health_check, pprof, zpages]
+  telemetry:
+    logs:
+      level: verbose
+    metrics:
+      level: none
+`,
+			wantErrors:   []string{"service.telemetry.logs.level"},
+			wantWarnings: []string{"service.telemetry.metrics.level"},
+			wantChecks:   []string{"extensions.health_check", "extensions.pprof", "extensions.zpages"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig("config.yaml", []byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := utils.NewReport()
+			checkTelemetry(report, c)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+			if got := utilstest.Subjects(report, utils.CHECKS); !slices.Equal(got, tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", got, tt.wantChecks)
+			}
+		})
+	}
+}
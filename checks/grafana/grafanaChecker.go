@@ -3,76 +3,84 @@ package grafana
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"regexp"
-	"slices"
 	"strings"
 
 	utils "otel-checker/checks/utils"
 )
 
+func init() {
+	utils.RegisterCheck(utils.NewCheck("grafana", "", nil, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckGrafanaSetup(report, env, commands.Language)
+	}))
+}
+
 func CheckGrafanaSetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	language string,
-	components []string,
 ) {
-	checkEnvVarsGrafana(messages, language, components)
-	checkAuth(messages)
+	checkEnvVarsGrafana(report, env, language)
+	checkAuth(report, env)
 }
 
+const otlpDocURL = "https://grafana.com/docs/grafana-cloud/send-data/otlp/send-data-otlp/"
+
 func checkEnvVarsGrafana(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	language string,
-	components []string,
 ) {
-	if os.Getenv("OTEL_SERVICE_NAME") == "" {
-		utils.AddWarning(messages, "Grafana Cloud", "It's recommended the environment variable OTEL_SERVICE_NAME to be set to your service name, for easier identification")
+	if env.Getenv("OTEL_SERVICE_NAME") == "" {
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "Grafana Cloud",
+			Subject:     "OTEL_SERVICE_NAME",
+			Message:     "It's recommended the environment variable OTEL_SERVICE_NAME to be set to your service name, for easier identification",
+			Remediation: `Run 'export OTEL_SERVICE_NAME="<your service name>"'`,
+		})
 	} else {
-		utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_SERVICE_NAME is set")
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "Grafana Cloud", Subject: "OTEL_SERVICE_NAME", Message: "OTEL_SERVICE_NAME is set"})
 	}
 
-	if os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL") != "http/protobuf" {
-		utils.AddError(messages, "Grafana Cloud", "OTEL_EXPORTER_OTLP_PROTOCOL is not set to 'http/protobuf'")
+	if env.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL") != "http/protobuf" {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Grafana Cloud",
+			Subject:     "OTEL_EXPORTER_OTLP_PROTOCOL",
+			Message:     "OTEL_EXPORTER_OTLP_PROTOCOL is not set to 'http/protobuf'",
+			Remediation: `Run 'export OTEL_EXPORTER_OTLP_PROTOCOL="http/protobuf"'`,
+			DocURL:      otlpDocURL,
+		})
 	} else {
-		utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_EXPORTER_OTLP_PROTOCOL set to 'http/protobuf'")
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "Grafana Cloud", Subject: "OTEL_EXPORTER_OTLP_PROTOCOL", Message: "OTEL_EXPORTER_OTLP_PROTOCOL set to 'http/protobuf'"})
 	}
 
-	if os.Getenv("OTEL_METRICS_EXPORTER") == "none" {
-		utils.AddError(messages, "Grafana Cloud", "The value of OTEL_METRICS_EXPORTER cannot be 'none'. Change the value to 'otlp' or leave it unset")
-	} else {
-		if os.Getenv("OTEL_METRICS_EXPORTER") == "" {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_METRICS_EXPORTER is unset, with a default value of 'otlp'")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", fmt.Sprintf("The value of OTEL_METRICS_EXPORTER is set to '%s'", os.Getenv("OTEL_METRICS_EXPORTER")))
-		}
-	}
-	if os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
-		utils.AddError(messages, "Grafana Cloud", "The value of OTEL_TRACES_EXPORTER cannot be 'none'. Change the value to 'otlp' or leave it unset")
-	} else {
-		if os.Getenv("OTEL_TRACES_EXPORTER") == "" {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_TRACES_EXPORTER is unset, with a default value of 'otlp'")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", fmt.Sprintf("The value of OTEL_TRACES_EXPORTER is set to '%s'", os.Getenv("OTEL_TRACES_EXPORTER")))
-		}
-	}
-	if os.Getenv("OTEL_LOGS_EXPORTER") == "none" {
-		utils.AddError(messages, "Grafana Cloud", "The value of OTEL_LOGS_EXPORTER cannot be 'none'. Change the value to 'otlp' or leave it unset")
-	} else {
-		if os.Getenv("OTEL_LOGS_EXPORTER") == "" {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_LOGS_EXPORTER is unset, with a default value of 'otlp'")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", fmt.Sprintf("The value of OTEL_LOGS_EXPORTER is set to '%s'", os.Getenv("OTEL_LOGS_EXPORTER")))
-		}
+	for _, envVar := range []string{"OTEL_METRICS_EXPORTER", "OTEL_TRACES_EXPORTER", "OTEL_LOGS_EXPORTER"} {
+		checkExporterEnvVar(report, env, envVar)
 	}
 
-	match, _ := regexp.MatchString("https:\\/\\/.+\\.grafana\\.net\\/otlp", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	match, _ := regexp.MatchString("https:\\/\\/.+\\.grafana\\.net\\/otlp", env.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
 	if match {
-		utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_EXPORTER_OTLP_ENDPOINT set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp")
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "Grafana Cloud", Subject: "OTEL_EXPORTER_OTLP_ENDPOINT", Message: "OTEL_EXPORTER_OTLP_ENDPOINT set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp"})
 	} else {
-		if strings.Contains(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "localhost") {
-			utils.AddWarning(messages, "Grafana Cloud", "OTEL_EXPORTER_OTLP_ENDPOINT is set to localhost. Update to a Grafana endpoint similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp to be able to send telemetry to your Grafana Cloud instance")
+		if strings.Contains(env.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "localhost") {
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "Grafana Cloud",
+				Subject:   "OTEL_EXPORTER_OTLP_ENDPOINT",
+				Message:   "OTEL_EXPORTER_OTLP_ENDPOINT is set to localhost. Update to a Grafana endpoint similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp to be able to send telemetry to your Grafana Cloud instance",
+				DocURL:    otlpDocURL,
+			})
 		} else {
-			utils.AddError(messages, "Grafana Cloud", "OTEL_EXPORTER_OTLP_ENDPOINT is not set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp")
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "Grafana Cloud",
+				Subject:     "OTEL_EXPORTER_OTLP_ENDPOINT",
+				Message:     "OTEL_EXPORTER_OTLP_ENDPOINT is not set in the format similar to https://otlp-gateway-prod-us-east-0.grafana.net/otlp",
+				Remediation: "Copy the OTLP endpoint from the OpenTelemetry section of your Grafana Cloud stack",
+				DocURL:      otlpDocURL,
+			})
 		}
 	}
 
@@ -80,61 +88,53 @@ func checkEnvVarsGrafana(
 	if language == "python" {
 		tokenStart = "Authorization=Basic%20"
 	}
-	if strings.Contains(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), tokenStart) {
-		utils.AddSuccessfulCheck(messages, "Grafana Cloud", "OTEL_EXPORTER_OTLP_HEADERS is set correctly")
+	if strings.Contains(env.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), tokenStart) {
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "Grafana Cloud", Subject: "OTEL_EXPORTER_OTLP_HEADERS", Message: "OTEL_EXPORTER_OTLP_HEADERS is set correctly"})
 	} else {
-		utils.AddError(messages, "Grafana Cloud", fmt.Sprintf("OTEL_EXPORTER_OTLP_HEADERS is not set. Value should have '%s...'", tokenStart))
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Grafana Cloud",
+			Subject:     "OTEL_EXPORTER_OTLP_HEADERS",
+			Message:     fmt.Sprintf("OTEL_EXPORTER_OTLP_HEADERS is not set. Value should have '%s...'", tokenStart),
+			Remediation: "Copy the OTLP headers from the OpenTelemetry section of your Grafana Cloud stack",
+			DocURL:      otlpDocURL,
+		})
 	}
+}
 
-	if slices.Contains(components, "beyla") {
-		if os.Getenv("BEYLA_SERVICE_NAME") == "" {
-			utils.AddWarning(messages, "Beyla", "It's recommended the environment variable BEYLA_SERVICE_NAME to be set to your service name")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Beyla", "BEYLA_SERVICE_NAME is set")
-		}
-
-		if os.Getenv("BEYLA_OPEN_PORT") == "" {
-			utils.AddError(messages, "Beyla", "BEYLA_OPEN_PORT must be set")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Beyla", "BEYLA_SERVICE_NAME is set")
-		}
-
-		if os.Getenv("GRAFANA_CLOUD_SUBMIT") == "" {
-			utils.AddError(messages, "Beyla", "GRAFANA_CLOUD_SUBMIT must be set to 'metrics' and/or 'traces'")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Beyla", "GRAFANA_CLOUD_SUBMIT is set correctly")
-		}
-
-		if os.Getenv("GRAFANA_CLOUD_INSTANCE_ID") == "" {
-			utils.AddError(messages, "Beyla", "GRAFANA_CLOUD_INSTANCE_ID must be set")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Beyla", "GRAFANA_CLOUD_INSTANCE_ID is set")
-		}
-
-		if os.Getenv("GRAFANA_CLOUD_API_KEY") == "" {
-			utils.AddError(messages, "Beyla", "GRAFANA_CLOUD_API_KEY must be set")
-		} else {
-			utils.AddSuccessfulCheck(messages, "Beyla", "GRAFANA_CLOUD_API_KEY is set")
-		}
+func checkExporterEnvVar(report *utils.Report, env utils.Env, envVar string) {
+	value := env.Getenv(envVar)
+	if value == "none" {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "Grafana Cloud",
+			Subject:     envVar,
+			Message:     fmt.Sprintf("The value of %s cannot be 'none'. Change the value to 'otlp' or leave it unset", envVar),
+			Remediation: fmt.Sprintf("Run 'unset %s'", envVar),
+		})
+	} else if value == "" {
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "Grafana Cloud", Subject: envVar, Message: fmt.Sprintf("%s is unset, with a default value of 'otlp'", envVar)})
+	} else {
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "Grafana Cloud", Subject: envVar, Message: fmt.Sprintf("The value of %s is set to '%s'", envVar, value)})
 	}
-
 }
 
-func checkAuth(messages *map[string][]string) {
-	if strings.Contains(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "localhost") {
-		utils.AddWarning(messages, "Grafana Cloud", "Credentials not checked, since OTEL_EXPORTER_OTLP_ENDPOINT is using localhost")
+func checkAuth(report *utils.Report, env utils.Env) {
+	if strings.Contains(env.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "localhost") {
+		utils.AddWarning(report, "Grafana Cloud", "Credentials not checked, since OTEL_EXPORTER_OTLP_ENDPOINT is using localhost")
 		return
 	}
-	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" || os.Getenv("OTEL_EXPORTER_OTLP_HEADERS") == "" {
-		utils.AddWarning(messages, "Grafana Cloud", "Credentials not checked, since both environment variables OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_EXPORTER_OTLP_HEADERS need to be set for this check")
+	if env.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" || env.Getenv("OTEL_EXPORTER_OTLP_HEADERS") == "" {
+		utils.AddWarning(report, "Grafana Cloud", "Credentials not checked, since both environment variables OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_EXPORTER_OTLP_HEADERS need to be set for this check")
 	} else {
-		endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") + "/v1/metrics"
-		req, err := http.NewRequest("POST", endpoint, nil)
+		endpoint := env.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") + "/v1/metrics"
+		req, err := http.NewRequestWithContext(env.Context, "POST", endpoint, nil)
 		if err != nil {
-			utils.AddError(messages, "Grafana Cloud", fmt.Sprintf("Error while testing credentials of OTEL_EXPORTER_OTLP_ENDPOINT: %s", err))
+			utils.AddError(report, "Grafana Cloud", fmt.Sprintf("Error while testing credentials of OTEL_EXPORTER_OTLP_ENDPOINT: %s", err))
+			return
 		}
 		authValue := ""
-		for _, h := range strings.SplitN(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",", -1) {
+		for _, h := range strings.SplitN(env.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",", -1) {
 			key, value, _ := strings.Cut(h, "=")
 			if key == "Authorization" {
 				authValue = value
@@ -142,16 +142,17 @@ func checkAuth(messages *map[string][]string) {
 		}
 		req.Header.Set("Authorization", authValue)
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := env.HTTPClient.Do(req)
 		if err != nil {
-			utils.AddError(messages, "Grafana Cloud", fmt.Sprintf("Error while testing credentials of OTEL_EXPORTER_OTLP_ENDPOINT: %s", err))
+			utils.AddError(report, "Grafana Cloud", fmt.Sprintf("Error while testing credentials of OTEL_EXPORTER_OTLP_ENDPOINT: %s", err))
+			return
 		}
+		defer resp.Body.Close()
 
 		if resp.StatusCode == 401 {
-			utils.AddError(messages, "Grafana Cloud", fmt.Sprintf("Error while testing credentials of OTEL_EXPORTER_OTLP_ENDPOINT: %s", resp.Status))
+			utils.AddError(report, "Grafana Cloud", fmt.Sprintf("Error while testing credentials of OTEL_EXPORTER_OTLP_ENDPOINT: %s", resp.Status))
 		} else {
-			utils.AddSuccessfulCheck(messages, "Grafana Cloud", "Credentials for OTEL_EXPORTER_OTLP_ENDPOINT are correct")
+			utils.AddSuccessfulCheck(report, "Grafana Cloud", "Credentials for OTEL_EXPORTER_OTLP_ENDPOINT are correct")
 		}
-		defer resp.Body.Close()
 	}
 }
@@ -0,0 +1,134 @@
+package grafana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckEnvVarsGrafana(t *testing.T) {
+	validEnv := map[string]string{
+		"OTEL_SERVICE_NAME":           "my-service",
+		"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp-gateway-prod-us-east-0.grafana.net/otlp",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "Authorization=Basic abc",
+	}
+	with := func(key, value string) map[string]string {
+		env := map[string]string{}
+		for k, v := range validEnv {
+			env[k] = v
+		}
+		env[key] = value
+		return env
+	}
+
+	tests := []struct {
+		name         string
+		language     string
+		env          map[string]string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:     "valid",
+			language: "js",
+			env:      validEnv,
+		},
+		{
+			name:         "empty",
+			language:     "js",
+			env:          map[string]string{},
+			wantErrors:   []string{"OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS"},
+			wantWarnings: []string{"OTEL_SERVICE_NAME"},
+		},
+		{
+			name:       "exporter disabled",
+			language:   "js",
+			env:        with("OTEL_TRACES_EXPORTER", "none"),
+			wantErrors: []string{"OTEL_TRACES_EXPORTER"},
+		},
+		{
+			name:         "localhost endpoint",
+			language:     "js",
+			env:          with("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+			wantWarnings: []string{"OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		{
+			name:       "python headers must be encoded",
+			language:   "python",
+			env:        validEnv,
+			wantErrors: []string{"OTEL_EXPORTER_OTLP_HEADERS"},
+		},
+		{
+			name:     "python encoded headers",
+			language: "python",
+			env:      with("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Basic%20abc"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(tt.env)}
+			checkEnvVarsGrafana(report, env, tt.language)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		endpoint     string
+		headers      string
+		wantSeverity utils.Severity
+	}{
+		{name: "valid credentials", status: http.StatusOK, headers: "Authorization=Basic abc", wantSeverity: utils.CHECKS},
+		{name: "invalid credentials", status: http.StatusUnauthorized, headers: "Authorization=Basic abc", wantSeverity: utils.ERRORS},
+		{name: "missing headers", status: http.StatusOK, wantSeverity: utils.WARNINGS},
+		{name: "localhost", status: http.StatusOK, endpoint: "http://localhost:4318", headers: "Authorization=Basic abc", wantSeverity: utils.WARNINGS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAuth = r.Header.Get("Authorization")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			endpoint := tt.endpoint
+			if endpoint == "" {
+				endpoint = server.URL
+			}
+			report := utils.NewReport()
+			env := utils.Env{
+				Context: context.Background(),
+				Getenv: utils.MapGetenv(map[string]string{
+					"OTEL_EXPORTER_OTLP_ENDPOINT": endpoint,
+					"OTEL_EXPORTER_OTLP_HEADERS":  tt.headers,
+				}),
+				HTTPClient: server.Client(),
+			}
+			checkAuth(report, env)
+
+			if len(report.Findings) != 1 || report.Findings[0].Severity != tt.wantSeverity {
+				t.Fatalf("findings = %v, want a single finding with severity %s", report.Findings, tt.wantSeverity)
+			}
+			if tt.wantSeverity != utils.WARNINGS && gotAuth != "Basic abc" {
+				t.Errorf("Authorization header = %q, want %q", gotAuth, "Basic abc")
+			}
+		})
+	}
+}
@@ -1,19 +1,22 @@
 package sdk
 
+import "otel-checker/checks/utils"
+
 func CheckDotNetSetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	autoInstrumentation bool,
 ) {
-	checkDotNetVersion(messages)
+	checkDotNetVersion(report)
 	if autoInstrumentation {
-		checkDotNetAutoInstrumentation(messages)
+		checkDotNetAutoInstrumentation(report)
 	} else {
-		checkDotNetCodeBasedInstrumentation(messages)
+		checkDotNetCodeBasedInstrumentation(report)
 	}
 }
 
-func checkDotNetVersion(messages *map[string][]string) {}
+func checkDotNetVersion(report *utils.Report) {}
 
-func checkDotNetAutoInstrumentation(messages *map[string][]string) {}
+func checkDotNetAutoInstrumentation(report *utils.Report) {}
 
-func checkDotNetCodeBasedInstrumentation(messages *map[string][]string) {}
+func checkDotNetCodeBasedInstrumentation(report *utils.Report) {}
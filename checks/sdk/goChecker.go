@@ -1,19 +1,22 @@
 package sdk
 
+import "otel-checker/checks/utils"
+
 func CheckGoSetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	autoInstrumentation bool,
 ) {
-	checkGoVersion(messages)
+	checkGoVersion(report)
 	if autoInstrumentation {
-		checkGoAutoInstrumentation(messages)
+		checkGoAutoInstrumentation(report)
 	} else {
-		checkGoCodeBasedInstrumentation(messages)
+		checkGoCodeBasedInstrumentation(report)
 	}
 }
 
-func checkGoVersion(messages *map[string][]string) {}
+func checkGoVersion(report *utils.Report) {}
 
-func checkGoAutoInstrumentation(messages *map[string][]string) {}
+func checkGoAutoInstrumentation(report *utils.Report) {}
 
-func checkGoCodeBasedInstrumentation(messages *map[string][]string) {}
+func checkGoCodeBasedInstrumentation(report *utils.Report) {}
@@ -0,0 +1,175 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// javaDependency is a dependency declared in a Maven or Gradle build file.
+type javaDependency struct {
+	Group    string
+	Artifact string
+	// Version is "" when the version is managed by a BOM, or not resolved.
+	Version string
+	// BOM is set for the dependencies importing a bill of materials, such as io.opentelemetry:opentelemetry-bom.
+	BOM  bool
+	Line int
+}
+
+func (d javaDependency) String() string {
+	s := d.Group + ":" + d.Artifact
+	if d.Version != "" {
+		s += ":" + d.Version
+	}
+	return s
+}
+
+// isBOM returns whether an artifact is a bill of materials, such as
+// opentelemetry-bom or opentelemetry-instrumentation-bom-alpha.
+func isBOM(artifact string) bool {
+	return strings.HasSuffix(artifact, "-bom") || strings.HasSuffix(artifact, "-bom-alpha")
+}
+
+// parseJavaBuildFile returns the dependencies of a pom.xml, build.gradle or
+// build.gradle.kts file, depending on the name of file.
+func parseJavaBuildFile(file string, data []byte) ([]javaDependency, error) {
+	if strings.HasSuffix(file, ".xml") {
+		return parsePom(data)
+	}
+	return parseGradle(data), nil
+}
+
+var pomProperty = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parsePom returns the dependencies of the project, and the ones of its
+// dependencyManagement section, with the ${...} properties of the project resolved.
+func parsePom(data []byte) ([]javaDependency, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	properties := map[string]string{}
+	var dependencies []javaDependency
+	var stack []string
+	var current *javaDependency
+	var dependencyType, scope string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			path := strings.Join(stack, "/")
+			if path == "project/dependencies/dependency" || path == "project/dependencyManagement/dependencies/dependency" {
+				line := bytes.Count(data[:decoder.InputOffset()], []byte("\n")) + 1
+				current = &javaDependency{Line: line}
+				dependencyType, scope = "", ""
+			}
+		case xml.EndElement:
+			if current != nil && t.Name.Local == "dependency" {
+				current.BOM = dependencyType == "pom" && scope == "import"
+				dependencies = append(dependencies, *current)
+				current = nil
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			value := strings.TrimSpace(string(t))
+			if value == "" || len(stack) < 2 {
+				continue
+			}
+			if len(stack) == 3 && stack[1] == "properties" {
+				properties[stack[2]] = value
+				continue
+			}
+			if current == nil || stack[len(stack)-2] != "dependency" {
+				continue
+			}
+			switch stack[len(stack)-1] {
+			case "groupId":
+				current.Group = value
+			case "artifactId":
+				current.Artifact = value
+			case "version":
+				current.Version = value
+			case "type":
+				dependencyType = value
+			case "scope":
+				scope = value
+			}
+		}
+	}
+
+	for i, d := range dependencies {
+		dependencies[i].Version = pomProperty.ReplaceAllStringFunc(d.Version, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			if name == "project.version" {
+				name = "version"
+			}
+			if value, ok := properties[name]; ok {
+				return value
+			}
+			return ref
+		})
+		if strings.Contains(dependencies[i].Version, "${") {
+			dependencies[i].Version = ""
+		}
+	}
+	return dependencies, nil
+}
+
+// Dependencies of Gradle build files, in the "group:artifact:version" notation,
+// e.g. implementation("io.opentelemetry:opentelemetry-api:1.32.0") or
+// implementation(platform("io.opentelemetry:opentelemetry-bom:1.32.0")), and in
+// the map notation, e.g. implementation group: 'io.opentelemetry', name: 'opentelemetry-api', version: '1.32.0'.
+var (
+	gradleDependency    = regexp.MustCompile(`["']([\w.-]+):([\w.-]+)(?::([^"':@]+))?(?:@\w+)?["']`)
+	gradleMapDependency = regexp.MustCompile(`group\s*[:=]\s*["']([\w.-]+)["']\s*,\s*name\s*[:=]\s*["']([\w.-]+)["'](?:\s*,\s*version\s*[:=]\s*["']([^"']+)["'])?`)
+	gradleVariable      = regexp.MustCompile(`^\s*(?:(?:val|var|def|ext\.)\s*)?([A-Za-z_][\w.]*)\s*=\s*["']([^"'$]+)["']\s*$`)
+	gradleReference     = regexp.MustCompile(`\$\{?([A-Za-z_][\w.]*)\}?`)
+)
+
+// parseGradle returns the dependencies of a build.gradle or build.gradle.kts
+// file. The versions in variables are resolved when the variables are set to
+// a string in the same file.
+func parseGradle(data []byte) []javaDependency {
+	lines := strings.Split(string(data), "\n")
+	variables := map[string]string{}
+	for _, line := range lines {
+		if m := gradleVariable.FindStringSubmatch(line); m != nil {
+			variables[strings.TrimPrefix(m[1], "ext.")] = m[2]
+		}
+	}
+
+	var dependencies []javaDependency
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		matches := gradleMapDependency.FindAllStringSubmatch(line, -1)
+		if matches == nil {
+			matches = gradleDependency.FindAllStringSubmatch(line, -1)
+		}
+		for _, m := range matches {
+			version := gradleReference.ReplaceAllStringFunc(m[3], func(ref string) string {
+				name := strings.Trim(ref, "${}")
+				if value, ok := variables[name]; ok {
+					return value
+				}
+				return ref
+			})
+			if strings.Contains(version, "$") {
+				version = ""
+			}
+			// A Gradle BOM is imported with platform() or enforcedPlatform(),
+			// or with mavenBom of the dependency management plugin.
+			bom := isBOM(m[2]) && (strings.Contains(line, "latform(") || strings.Contains(line, "mavenBom"))
+			dependencies = append(dependencies, javaDependency{Group: m[1], Artifact: m[2], Version: version, BOM: bom, Line: i + 1})
+		}
+	}
+	return dependencies
+}
@@ -1,19 +1,383 @@
 package sdk
 
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"otel-checker/checks/utils"
+)
+
+const (
+	javaAgentDocURL = "https://opentelemetry.io/docs/zero-code/java/agent/"
+	javaSDKDocURL   = "https://opentelemetry.io/docs/languages/java/"
+	// The Java agent and SDK support Java 8 and later.
+	minJava = 8
+	// Versions of the Java agent before 2.0 export with gRPC by default, instead of http/protobuf.
+	minJavaAgent = "2.0.0"
+)
+
+// Environment variables the JVM reads options, such as -javaagent:, from.
+// JDK_JAVA_OPTIONS is only read by the java launcher of Java 9 and later.
+var javaOptionsEnvVars = []string{"JAVA_TOOL_OPTIONS", "JDK_JAVA_OPTIONS"}
+
+// Build files looked for in the current directory when -build-file-path is not passed.
+var javaBuildFiles = []string{"pom.xml", "build.gradle.kts", "build.gradle"}
+
+var (
+	// E.g. `openjdk version "1.8.0_392"` or `java version "21" 2023-09-19 LTS`.
+	javaVersionOutput = regexp.MustCompile(`(?m)^\S+ version "(\d+(?:[._]\d+)*)`)
+	// E.g. JAVA_VERSION="1.8.0_392".
+	javaReleaseVersion = regexp.MustCompile(`(?m)^JAVA_VERSION="([^"]+)"`)
+)
+
 func CheckJavaSetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	autoInstrumentation bool,
+	buildFilePath string,
 ) {
-	checkJavaVersion(messages)
+	checkJavaVersion(report, env)
 	if autoInstrumentation {
-		checkJavaAutoInstrumentation(messages)
+		checkJavaAutoInstrumentation(report, env)
 	} else {
-		checkJavaCodeBasedInstrumentation(messages)
+		checkJavaCodeBasedInstrumentation(report, env, buildFilePath)
+	}
+}
+
+// checkJavaVersion checks the version of the java in the PATH, or of the one
+// in JAVA_HOME. It runs "java -version", since "java --version" only exists
+// since Java 9.
+func checkJavaVersion(report *utils.Report, env utils.Env) {
+	version, source := "", "java -version"
+	if output, err := env.CombinedOutput("java", "-version"); err == nil {
+		if m := javaVersionOutput.FindStringSubmatch(string(output)); m != nil {
+			version = m[1]
+		}
+	}
+	if version == "" && env.Getenv("JAVA_HOME") != "" {
+		source = path.Join(env.Getenv("JAVA_HOME"), "release")
+		if data, err := env.ReadFile(source); err == nil {
+			if m := javaReleaseVersion.FindSubmatch(data); m != nil {
+				version = string(m[1])
+			}
+		}
+	}
+	if version == "" {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     "java",
+			Message:     "Could not check minimum Java version: java -version failed and JAVA_HOME/release could not be read",
+			Remediation: "Add the java of your application to the PATH, or set JAVA_HOME to its installation directory",
+			DocURL:      javaSDKDocURL,
+		})
+		return
+	}
+
+	major := javaMajorVersion(version)
+	if major >= minJava {
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: "java", Message: fmt.Sprintf("Using Java version %s, equal or greater than the minimum %d (from %s)", version, minJava, source)})
+	} else {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     "java",
+			Message:     fmt.Sprintf("Using Java version %s (from %s), which is not supported by OpenTelemetry. Update your Java to at least version %d", version, source, minJava),
+			Remediation: fmt.Sprintf("Update your Java to at least version %d", minJava),
+			DocURL:      javaSDKDocURL,
+		})
+	}
+}
+
+// javaMajorVersion returns the major version of a Java version, such as 8 for
+// "1.8.0_392" and 17 for "17.0.9", or 0 when it is not a version.
+func javaMajorVersion(version string) int {
+	parts := strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '_' })
+	if len(parts) > 1 && parts[0] == "1" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return 0
+	}
+	major, _ := strconv.Atoi(parts[0])
+	return major
+}
+
+// javaAgent returns the path of the jar passed with -javaagent: in the
+// options of the JVM, and the environment variable it is in.
+func javaAgent(env utils.Env) (string, string) {
+	for _, envVar := range javaOptionsEnvVars {
+		for _, option := range strings.Fields(env.Getenv(envVar)) {
+			if jar, ok := strings.CutPrefix(option, "-javaagent:"); ok {
+				// The agent options follow the path of the jar, after "=".
+				jar, _, _ = strings.Cut(jar, "=")
+				return strings.Trim(jar, `"'`), envVar
+			}
+		}
+	}
+	return "", ""
+}
+
+func checkJavaAutoInstrumentation(report *utils.Report, env utils.Env) {
+	jar, envVar := javaAgent(env)
+	if jar == "" {
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "SDK",
+			Subject:     "JAVA_TOOL_OPTIONS",
+			Message:     `No -javaagent: option in JAVA_TOOL_OPTIONS or JDK_JAVA_OPTIONS. You can set it by running 'export JAVA_TOOL_OPTIONS="-javaagent:/path/to/opentelemetry-javaagent.jar"' or add the same '-javaagent:...' when starting your application`,
+			Remediation: `Run 'export JAVA_TOOL_OPTIONS="-javaagent:/path/to/opentelemetry-javaagent.jar"'`,
+			DocURL:      javaAgentDocURL,
+		})
+		return
+	}
+	report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: envVar, Message: fmt.Sprintf("%s loads the Java agent %s", envVar, jar)})
+
+	data, err := env.ReadFile(jar)
+	if err != nil {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     jar,
+			Location:    &utils.Location{File: jar},
+			Message:     fmt.Sprintf("Could not read the Java agent %s passed with -javaagent: in %s: %s", jar, envVar, err),
+			Remediation: "Download opentelemetry-javaagent.jar from https://github.com/open-telemetry/opentelemetry-java-instrumentation/releases and pass its path with -javaagent:",
+			DocURL:      javaAgentDocURL,
+		})
+		return
+	}
+	manifest, err := jarManifest(data)
+	if err != nil {
+		report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Subject: jar, Location: &utils.Location{File: jar}, Message: fmt.Sprintf("Could not read the manifest of the Java agent %s: %s", jar, err), DocURL: javaAgentDocURL})
+		return
+	}
+	if !strings.HasPrefix(manifest["Premain-Class"], "io.opentelemetry.javaagent.") {
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "SDK",
+			Subject:     jar,
+			Location:    &utils.Location{File: jar},
+			Message:     fmt.Sprintf("%s is not the OpenTelemetry Java agent, or a distribution of it: its Premain-Class is %q", jar, manifest["Premain-Class"]),
+			Remediation: "Pass the path of opentelemetry-javaagent.jar, or of grafana-opentelemetry-java.jar, with -javaagent:",
+			DocURL:      javaAgentDocURL,
+		})
+		return
+	}
+
+	version := manifest["Implementation-Version"]
+	switch {
+	case !utils.ValidVersion(version):
+		report.Add(utils.Finding{Severity: utils.WARNINGS, Component: "SDK", Subject: jar, Location: &utils.Location{File: jar}, Message: fmt.Sprintf("Could not check the version of the Java agent %s: its Implementation-Version is %q", jar, version), DocURL: javaAgentDocURL})
+	case utils.CompareVersions(version, minJavaAgent) < 0:
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     jar,
+			Location:    &utils.Location{File: jar},
+			Message:     fmt.Sprintf("The Java agent %s is version %s. Versions before %s export with gRPC by default, and the http/protobuf protocol of Grafana Cloud is not set for all of them", jar, version, minJavaAgent),
+			Remediation: fmt.Sprintf("Update the Java agent to at least version %s", minJavaAgent),
+			DocURL:      javaAgentDocURL,
+		})
+	default:
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: jar, Location: &utils.Location{File: jar}, Message: fmt.Sprintf("Using Java agent version %s, equal or greater than the minimum %s", version, minJavaAgent)})
 	}
 }
 
-func checkJavaVersion(messages *map[string][]string) {}
+// jarManifest returns the main attributes of the META-INF/MANIFEST.MF of a jar.
+func jarManifest(data []byte) (map[string]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	f, err := archive.Open("META-INF/MANIFEST.MF")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{}
+	var last string
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if line == "" {
+			// The main attributes end at the first empty line.
+			break
+		}
+		// Long values continue on the following lines, which start with a space.
+		if strings.HasPrefix(line, " ") && last != "" {
+			attributes[last] += line[1:]
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ": "); ok {
+			attributes[name], last = value, name
+		}
+	}
+	return attributes, nil
+}
+
+// Artifacts providing the API, the SDK and the OTLP exporter, which is what is
+// needed to send to Grafana Cloud, and the ones that bring all of them.
+var (
+	javaRequiredArtifacts = []string{"opentelemetry-api", "opentelemetry-sdk", "opentelemetry-exporter-otlp"}
+	javaStarterArtifacts  = []string{"opentelemetry-spring-boot-starter"}
+)
+
+func checkJavaCodeBasedInstrumentation(report *utils.Report, env utils.Env, buildFilePath string) {
+	if jar, envVar := javaAgent(env); jar != "" {
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "SDK",
+			Subject:     envVar,
+			Message:     fmt.Sprintf(`The flag "-auto-instrumentation" was not passed to otel-checker, but %s loads the Java agent %s, which configures its own SDK. Pass "-auto-instrumentation", or remove -javaagent: from %s if the SDK is set up in the code`, envVar, jar, envVar),
+			Remediation: fmt.Sprintf("Remove -javaagent: from %s", envVar),
+			DocURL:      javaAgentDocURL,
+		})
+	}
+
+	file := buildFilePath
+	if file == "" {
+		for _, name := range javaBuildFiles {
+			if _, err := env.Stat(name); err == nil {
+				file = name
+				break
+			}
+		}
+		if file == "" {
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "SDK",
+				Message:     fmt.Sprintf("Could not find a build file (%s) in the current directory", strings.Join(javaBuildFiles, ", ")),
+				Remediation: `Pass the path of the build file of your application with "-build-file-path"`,
+			})
+			return
+		}
+	}
+	data, err := env.ReadFile(file)
+	if err != nil {
+		report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Location: &utils.Location{File: file}, Message: fmt.Sprintf("Could not check file %s: %s", file, err)})
+		return
+	}
+	dependencies, err := parseJavaBuildFile(file, data)
+	if err != nil {
+		report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Location: &utils.Location{File: file}, Message: fmt.Sprintf("Could not parse file %s: %s", file, err)})
+		return
+	}
+
+	var otel []javaDependency
+	for _, d := range dependencies {
+		if d.Group == "io.opentelemetry" || strings.HasPrefix(d.Group, "io.opentelemetry.") {
+			otel = append(otel, d)
+		}
+	}
+	checkJavaDependencies(report, file, otel)
+	checkJavaBOM(report, file, otel)
+	checkJavaVersions(report, file, otel)
+}
+
+// checkJavaDependencies checks that the artifacts needed to send to Grafana
+// Cloud are dependencies, and that the logging exporters are not.
+func checkJavaDependencies(report *utils.Report, file string, dependencies []javaDependency) {
+	has := func(artifact string) bool {
+		return slices.ContainsFunc(dependencies, func(d javaDependency) bool { return d.Artifact == artifact })
+	}
+	starter := slices.ContainsFunc(javaStarterArtifacts, has)
+	for _, artifact := range javaRequiredArtifacts {
+		if has(artifact) || starter {
+			report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: artifact, Location: &utils.Location{File: file}, Message: fmt.Sprintf("Dependency io.opentelemetry:%s added on %s", artifact, path.Base(file))})
+		} else {
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "SDK",
+				Subject:     artifact,
+				Location:    &utils.Location{File: file},
+				Message:     fmt.Sprintf("Dependency io.opentelemetry:%s missing on %s", artifact, path.Base(file)),
+				Remediation: fmt.Sprintf("Add the io.opentelemetry:%s dependency to %s", artifact, path.Base(file)),
+				DocURL:      javaSDKDocURL,
+			})
+		}
+	}
 
-func checkJavaAutoInstrumentation(messages *map[string][]string) {}
+	for _, d := range dependencies {
+		if d.Artifact == "opentelemetry-exporter-logging" || d.Artifact == "opentelemetry-exporter-logging-otlp" {
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "SDK",
+				Subject:   d.Artifact,
+				Location:  &utils.Location{File: file, Line: d.Line},
+				Message:   fmt.Sprintf("Dependency %s added on %s. The logging exporters are useful during debugging, but use the OTLP exporter of opentelemetry-exporter-otlp to send to Grafana Cloud", d, path.Base(file)),
+			})
+		}
+	}
+}
 
-func checkJavaCodeBasedInstrumentation(messages *map[string][]string) {}
+// checkJavaBOM checks that the versions of the OpenTelemetry artifacts are
+// managed by a BOM, which keeps them compatible with each other.
+func checkJavaBOM(report *utils.Report, file string, dependencies []javaDependency) {
+	for _, d := range dependencies {
+		if d.BOM {
+			report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: d.Artifact, Location: &utils.Location{File: file, Line: d.Line}, Message: fmt.Sprintf("Versions of the OpenTelemetry dependencies managed by the %s BOM", d)})
+			return
+		}
+	}
+	if len(dependencies) > 1 {
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "SDK",
+			Subject:     "opentelemetry-bom",
+			Location:    &utils.Location{File: file},
+			Message:     fmt.Sprintf("The versions of the OpenTelemetry dependencies on %s are not managed by a BOM, so they can get out of sync", path.Base(file)),
+			Remediation: "Import the io.opentelemetry:opentelemetry-bom BOM, or io.opentelemetry.instrumentation:opentelemetry-instrumentation-bom, and remove the versions of the OpenTelemetry dependencies",
+			DocURL:      javaSDKDocURL,
+		})
+	}
+}
+
+// checkJavaVersions checks that the OpenTelemetry artifacts of a same group,
+// and the BOM of the group, have the same version. The "-alpha" artifacts are
+// released with the same version followed by "-alpha".
+func checkJavaVersions(report *utils.Report, file string, dependencies []javaDependency) {
+	byGroup := map[string][]javaDependency{}
+	for _, d := range dependencies {
+		if utils.ValidVersion(d.Version) {
+			byGroup[d.Group] = append(byGroup[d.Group], d)
+		}
+	}
+	var groups []string
+	for group := range byGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		versioned := byGroup[group]
+		var mismatched []string
+		for _, d := range versioned {
+			if utils.CompareVersions(d.Version, versioned[0].Version) != 0 {
+				mismatched = append(mismatched, d.String())
+			}
+		}
+		if len(mismatched) == 0 {
+			continue
+		}
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     group,
+			Location:    &utils.Location{File: file, Line: versioned[0].Line},
+			Message:     fmt.Sprintf("The %s dependencies on %s have different versions: %s, but %s. Mixing versions can fail at runtime", group, path.Base(file), versioned[0], strings.Join(mismatched, ", ")),
+			Remediation: "Use the same version for all of them, or import the BOM of the group and remove their versions",
+			DocURL:      javaSDKDocURL,
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package sdk
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckJavaVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		err          error
+		env          map[string]string
+		files        fstest.MapFS
+		wantSeverity utils.Severity
+		wantMessage  string
+	}{
+		{name: "openjdk", output: "openjdk version \"17.0.9\" 2023-10-17\nOpenJDK Runtime Environment (build 17.0.9+9)\n", wantSeverity: utils.CHECKS, wantMessage: "17.0.9"},
+		{name: "major version only", output: "java version \"21\" 2023-09-19 LTS\n", wantSeverity: utils.CHECKS, wantMessage: "21"},
+		{
+			name:         "java 8 without JAVA_HOME",
+			output:       "openjdk version \"1.8.0_392\"\nOpenJDK Runtime Environment (build 1.8.0_392-b08)\nOpenJDK 64-Bit Server VM (build 25.392-b08, mixed mode)\n",
+			wantSeverity: utils.CHECKS,
+			wantMessage:  "1.8.0_392",
+		},
+		{
+			name:         "java tool options",
+			output:       "Picked up JAVA_TOOL_OPTIONS: -javaagent:opentelemetry-javaagent.jar\nopenjdk version \"11.0.21\" 2023-10-17\n",
+			wantSeverity: utils.CHECKS,
+			wantMessage:  "11.0.21",
+		},
+		{name: "java 7", output: "java version \"1.7.0_80\"\n", wantSeverity: utils.ERRORS, wantMessage: "1.7.0_80"},
+		{
+			name:         "java 8 from JAVA_HOME",
+			err:          errors.New("exit status 1"),
+			env:          map[string]string{"JAVA_HOME": "/usr/lib/jvm/java-8"},
+			files:        fstest.MapFS{"usr/lib/jvm/java-8/release": {Data: []byte("JAVA_VERSION=\"1.8.0_392\"\nOS_NAME=\"Linux\"\n")}},
+			wantSeverity: utils.CHECKS,
+			wantMessage:  "/usr/lib/jvm/java-8/release",
+		},
+		{
+			name:         "java 7 from JAVA_HOME",
+			err:          errors.New("exit status 1"),
+			env:          map[string]string{"JAVA_HOME": "/usr/lib/jvm/java-7"},
+			files:        fstest.MapFS{"usr/lib/jvm/java-7/release": {Data: []byte("JAVA_VERSION=\"1.7.0_80\"\n")}},
+			wantSeverity: utils.ERRORS,
+		},
+		{name: "java not installed", err: errors.New(`exec: "java": executable file not found in $PATH`), wantSeverity: utils.ERRORS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			env := utils.Env{
+				Getenv: utils.MapGetenv(tt.env),
+				FS:     tt.files,
+				RunCommand: func(name string, args ...string) ([]byte, error) {
+					if name != "java" || !slices.Equal(args, []string{"-version"}) {
+						t.Errorf("ran %s %v, want java -version", name, args)
+					}
+					return []byte(tt.output), tt.err
+				},
+			}
+			checkJavaVersion(report, env)
+
+			if len(report.Findings) != 1 || report.Findings[0].Severity != tt.wantSeverity || !strings.Contains(report.Findings[0].Message, tt.wantMessage) {
+				t.Errorf("findings = %v, want a single finding with severity %s containing %q", report.Findings, tt.wantSeverity, tt.wantMessage)
+			}
+		})
+	}
+}
+
+// jar returns a jar containing only a META-INF/MANIFEST.MF with content manifest.
+func jar(t *testing.T, manifest string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("META-INF/MANIFEST.MF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte(manifest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckJavaAutoInstrumentation(t *testing.T) {
+	agent := func(version string) []byte {
+		return jar(t, "Manifest-Version: 1.0\r\nImplementation-Title: javaagent\r\nImplementation-Version: "+version+"\r\nPremain-Class: io.opentelemetry.javaagent.Op\r\n enTelemetryAgent\r\n\r\nName: other\r\nImplementation-Version: 0.1\r\n")
+	}
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		files        fstest.MapFS
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name:  "valid",
+			env:   map[string]string{"JAVA_TOOL_OPTIONS": "-Xmx1g -javaagent:/opt/otel/opentelemetry-javaagent.jar"},
+			files: fstest.MapFS{"opt/otel/opentelemetry-javaagent.jar": {Data: agent("2.10.0")}},
+		},
+		{
+			name:  "agent options in JDK_JAVA_OPTIONS",
+			env:   map[string]string{"JDK_JAVA_OPTIONS": "-javaagent:agent.jar=debug"},
+			files: fstest.MapFS{"agent.jar": {Data: agent("2.0.0")}},
+		},
+		{
+			name:         "no agent",
+			env:          map[string]string{"JAVA_TOOL_OPTIONS": "-Xmx1g"},
+			wantWarnings: []string{"JAVA_TOOL_OPTIONS"},
+		},
+		{
+			name:       "missing jar",
+			env:        map[string]string{"JAVA_TOOL_OPTIONS": "-javaagent:/opt/otel/opentelemetry-javaagent.jar"},
+			wantErrors: []string{"/opt/otel/opentelemetry-javaagent.jar"},
+		},
+		{
+			name:       "old agent",
+			env:        map[string]string{"JAVA_TOOL_OPTIONS": "-javaagent:agent.jar"},
+			files:      fstest.MapFS{"agent.jar": {Data: agent("1.32.0")}},
+			wantErrors: []string{"agent.jar"},
+		},
+		{
+			name:         "other agent",
+			env:          map[string]string{"JAVA_TOOL_OPTIONS": "-javaagent:agent.jar"},
+			files:        fstest.MapFS{"agent.jar": {Data: jar(t, "Manifest-Version: 1.0\nPremain-Class: com.example.Agent\n")}},
+			wantWarnings: []string{"agent.jar"},
+		},
+		{
+			name:       "not a jar",
+			env:        map[string]string{"JAVA_TOOL_OPTIONS": "-javaagent:agent.jar"},
+			files:      fstest.MapFS{"agent.jar": {Data: []byte("not a jar")}},
+			wantErrors: []string{"agent.jar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			checkJavaAutoInstrumentation(report, utils.Env{Getenv: utils.MapGetenv(tt.env), FS: tt.files})
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestParseJavaBuildFile(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+		want []string
+	}{
+		{
+			file: "pom.xml",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <version>1.0.0</version>
+  <properties>
+    <otel.version>1.32.0</otel.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.opentelemetry</groupId>
+        <artifactId>opentelemetry-bom</artifactId>
+        <version>${otel.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>io.opentelemetry</groupId>
+      <artifactId>opentelemetry-api</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>lib</artifactId>
+      <version>${lib.version}</version>
+    </dependency>
+  </dependencies>
+  <build>
+    <plugins>
+      <plugin>
+        <dependencies>
+          <dependency>
+            <groupId>com.example</groupId>
+            <artifactId>plugin-dependency</artifactId>
+          </dependency>
+        </dependencies>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`,
+			want: []string{"io.opentelemetry:opentelemetry-bom:1.32.0 bom line 9", "io.opentelemetry:opentelemetry-api line 19", "com.example:lib line 23"},
+		},
+		{
+			file: "build.gradle.kts",
+			data: `val otelVersion = "1.32.0"
+
+dependencies {
+    implementation(platform("io.opentelemetry.instrumentation:opentelemetry-instrumentation-bom:2.10.0"))
+    implementation("io.opentelemetry:opentelemetry-api:$otelVersion")
+    implementation("io.opentelemetry:opentelemetry-sdk:${otelVersion}")
+    // implementation("io.opentelemetry:opentelemetry-exporter-logging:1.32.0")
+    runtimeOnly("io.opentelemetry:opentelemetry-exporter-otlp:$unknownVersion")
+}
+`,
+			want: []string{
+				"io.opentelemetry.instrumentation:opentelemetry-instrumentation-bom:2.10.0 bom line 4",
+				"io.opentelemetry:opentelemetry-api:1.32.0 line 5",
+				"io.opentelemetry:opentelemetry-sdk:1.32.0 line 6",
+				"io.opentelemetry:opentelemetry-exporter-otlp line 8",
+			},
+		},
+		{
+			file: "build.gradle",
+			data: `ext {
+    otelVersion = '1.31.0'
+}
+dependencies {
+    implementation group: 'io.opentelemetry', name: 'opentelemetry-api', version: otelVersion
+    implementation group: 'io.opentelemetry', name: 'opentelemetry-sdk', version: '1.31.0'
+    implementation 'io.opentelemetry:opentelemetry-exporter-otlp'
+}
+`,
+			want: []string{"io.opentelemetry:opentelemetry-api line 5", "io.opentelemetry:opentelemetry-sdk:1.31.0 line 6", "io.opentelemetry:opentelemetry-exporter-otlp line 7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			dependencies, err := parseJavaBuildFile(tt.file, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range dependencies {
+				s := d.String()
+				if d.BOM {
+					s += " bom"
+				}
+				got = append(got, fmt.Sprintf("%s line %d", s, d.Line))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("dependencies = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseJavaBuildFile("pom.xml", []byte("<project><dependencies>")); err == nil {
+		t.Error("expected an error for an invalid pom.xml")
+	}
+}
+
+func TestCheckJavaCodeBasedInstrumentation(t *testing.T) {
+	const pom = `<project>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.opentelemetry</groupId>
+        <artifactId>opentelemetry-bom</artifactId>
+        <version>1.32.0</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency><groupId>io.opentelemetry</groupId><artifactId>opentelemetry-api</artifactId></dependency>
+    <dependency><groupId>io.opentelemetry</groupId><artifactId>opentelemetry-sdk</artifactId></dependency>
+    <dependency><groupId>io.opentelemetry</groupId><artifactId>opentelemetry-exporter-otlp</artifactId></dependency>
+  </dependencies>
+</project>
+`
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		files         fstest.MapFS
+		buildFilePath string
+		wantErrors    []string
+		wantWarnings  []string
+	}{
+		{name: "pom.xml with a BOM", files: fstest.MapFS{"pom.xml": {Data: []byte(pom)}}},
+		{
+			name:          "spring boot starter",
+			files:         fstest.MapFS{"app/build.gradle.kts": {Data: []byte(`implementation("io.opentelemetry.instrumentation:opentelemetry-spring-boot-starter:2.10.0")`)}},
+			buildFilePath: "app/build.gradle.kts",
+		},
+		{
+			name: "mismatched versions without BOM",
+			files: fstest.MapFS{"build.gradle": {Data: []byte(`dependencies {
+    implementation 'io.opentelemetry:opentelemetry-api:1.32.0'
+    implementation 'io.opentelemetry:opentelemetry-sdk:1.31.0'
+    implementation 'io.opentelemetry:opentelemetry-exporter-otlp:1.32.0'
+    implementation 'io.opentelemetry:opentelemetry-exporter-logging:1.32.0'
+    implementation 'io.opentelemetry:opentelemetry-sdk-extension-incubator:1.32.0-alpha'
+}`)}},
+			wantErrors:   []string{"io.opentelemetry"},
+			wantWarnings: []string{"opentelemetry-exporter-logging", "opentelemetry-bom"},
+		},
+		{
+			name:         "missing dependencies and Java agent",
+			env:          map[string]string{"JAVA_TOOL_OPTIONS": "-javaagent:agent.jar"},
+			files:        fstest.MapFS{"pom.xml": {Data: []byte("<project><dependencies></dependencies></project>")}},
+			wantErrors:   []string{"opentelemetry-api", "opentelemetry-sdk", "opentelemetry-exporter-otlp"},
+			wantWarnings: []string{"JAVA_TOOL_OPTIONS"},
+		},
+		{name: "no build file", files: fstest.MapFS{}, wantErrors: []string{""}},
+		{name: "missing build file", files: fstest.MapFS{}, buildFilePath: "app/pom.xml", wantErrors: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			checkJavaCodeBasedInstrumentation(report, utils.Env{Getenv: utils.MapGetenv(tt.env), FS: tt.files}, tt.buildFilePath)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -2,8 +2,6 @@ package sdk
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 
@@ -11,118 +9,178 @@ import (
 )
 
 func CheckJSSetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	autoInstrumentation bool,
 	packageJsonPath string,
 	instrumentationFile string,
 ) {
-	checkEnvVars(messages)
-	checkNodeVersion(messages)
+	checkEnvVars(report, env)
+	checkNodeVersion(report, env)
 	if autoInstrumentation {
-		checkJSAutoInstrumentation(messages, packageJsonPath)
+		checkJSAutoInstrumentation(report, env, packageJsonPath)
 	} else {
-		checkJSCodeBasedInstrumentation(messages, packageJsonPath, instrumentationFile)
+		checkJSCodeBasedInstrumentation(report, env, packageJsonPath, instrumentationFile)
 	}
 }
 
-func checkEnvVars(messages *map[string][]string) {
-	if os.Getenv("OTEL_NODE_RESOURCE_DETECTORS") == "" ||
-		!strings.Contains(os.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "env") ||
-		!strings.Contains(os.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "host") ||
-		!strings.Contains(os.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "os") ||
-		!strings.Contains(os.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "serviceinstance") {
-		utils.AddWarning(messages, "SDK", "It's recommended the environment variable OTEL_NODE_RESOURCE_DETECTORS to be set to at least `env,host,os,serviceinstance`")
+func checkEnvVars(report *utils.Report, env utils.Env) {
+	if env.Getenv("OTEL_NODE_RESOURCE_DETECTORS") == "" ||
+		!strings.Contains(env.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "env") ||
+		!strings.Contains(env.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "host") ||
+		!strings.Contains(env.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "os") ||
+		!strings.Contains(env.Getenv("OTEL_NODE_RESOURCE_DETECTORS"), "serviceinstance") {
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "SDK",
+			Subject:     "OTEL_NODE_RESOURCE_DETECTORS",
+			Message:     "It's recommended the environment variable OTEL_NODE_RESOURCE_DETECTORS to be set to at least `env,host,os,serviceinstance`",
+			Remediation: `Run 'export OTEL_NODE_RESOURCE_DETECTORS="env,host,os,serviceinstance"'`,
+		})
 	} else {
-		utils.AddSuccessfulCheck(messages, "SDK", "OTEL_NODE_RESOURCE_DETECTORS has recommended values")
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: "OTEL_NODE_RESOURCE_DETECTORS", Message: "OTEL_NODE_RESOURCE_DETECTORS has recommended values"})
 	}
 }
 
-func checkNodeVersion(messages *map[string][]string) {
-	cmd := exec.Command("node", "-v")
-	stdout, err := cmd.Output()
-
+func checkNodeVersion(report *utils.Report, env utils.Env) {
+	stdout, err := env.Output("node", "-v")
 	if err != nil {
-		utils.AddError(messages, "SDK", fmt.Sprintf("Could not check minimum node version: %s", err))
+		utils.AddError(report, "SDK", fmt.Sprintf("Could not check minimum node version: %s", err))
+		return
 	}
-	versionInfo := strings.Split(string(stdout), ".")
-	v, err := strconv.Atoi(versionInfo[0][1:])
+	versionInfo := strings.Split(strings.TrimPrefix(strings.TrimSpace(string(stdout)), "v"), ".")
+	v, err := strconv.Atoi(versionInfo[0])
 	if err != nil {
-		utils.AddError(messages, "SDK", fmt.Sprintf("Could not check minimum node version: %s", err))
+		utils.AddError(report, "SDK", fmt.Sprintf("Could not check minimum node version: %s", err))
+		return
 	}
 	if v >= 16 {
-		utils.AddSuccessfulCheck(messages, "SDK", "Using node version equal or greater than minimum recommended")
+		utils.AddSuccessfulCheck(report, "SDK", "Using node version equal or greater than minimum recommended")
 	} else {
-		utils.AddError(messages, "SDK", "Not using recommended node version. Update your node to at least version 16")
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     "node",
+			Message:     "Not using recommended node version. Update your node to at least version 16",
+			Remediation: "Update your node to at least version 16",
+		})
 	}
 }
 
 func checkJSAutoInstrumentation(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	packageJsonPath string,
 ) {
 	// NODE_OPTIONS should be set or that requirement should be added when starting the app
-	if os.Getenv("NODE_OPTIONS") == "--require @opentelemetry/auto-instrumentations-node/register" {
-		utils.AddSuccessfulCheck(messages, "SDK", "NODE_OPTIONS set correctly")
+	if env.Getenv("NODE_OPTIONS") == "--require @opentelemetry/auto-instrumentations-node/register" {
+		report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: "NODE_OPTIONS", Message: "NODE_OPTIONS set correctly"})
 	} else {
-		utils.AddWarning(messages, "SDK", `NODE_OPTIONS not set. You can set it by running 'export NODE_OPTIONS="--require @opentelemetry/auto-instrumentations-node/register"' or add the same '--require ...' when starting your application`)
+		report.Add(utils.Finding{
+			Severity:    utils.WARNINGS,
+			Component:   "SDK",
+			Subject:     "NODE_OPTIONS",
+			Message:     `NODE_OPTIONS not set. You can set it by running 'export NODE_OPTIONS="--require @opentelemetry/auto-instrumentations-node/register"' or add the same '--require ...' when starting your application`,
+			Remediation: `Run 'export NODE_OPTIONS="--require @opentelemetry/auto-instrumentations-node/register"'`,
+		})
 	}
 
 	// Dependencies for auto instrumentation on package.json
 	filePath := packageJsonPath + "package.json"
-	dat, err := os.ReadFile(filePath)
+	dat, err := env.ReadFile(filePath)
 	if err != nil {
-		utils.AddError(messages, "SDK", fmt.Sprintf("Could not check file %s: %s", filePath, err))
+		report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Location: &utils.Location{File: filePath}, Message: fmt.Sprintf("Could not check file %s: %s", filePath, err)})
 	} else {
 		if strings.Contains(string(dat), `"@opentelemetry/auto-instrumentations-node"`) {
-			utils.AddSuccessfulCheck(messages, "SDK", "Dependency @opentelemetry/auto-instrumentations-node added on package.json")
+			report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: "@opentelemetry/auto-instrumentations-node", Location: &utils.Location{File: filePath}, Message: "Dependency @opentelemetry/auto-instrumentations-node added on package.json"})
 		} else {
-			utils.AddError(messages, "SDK", "Dependency @opentelemetry/auto-instrumentations-node missing on package.json. Install the dependency with `npm install @opentelemetry/auto-instrumentations-node`")
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "SDK",
+				Subject:     "@opentelemetry/auto-instrumentations-node",
+				Location:    &utils.Location{File: filePath},
+				Message:     "Dependency @opentelemetry/auto-instrumentations-node missing on package.json. Install the dependency with `npm install @opentelemetry/auto-instrumentations-node`",
+				Remediation: "Run `npm install @opentelemetry/auto-instrumentations-node`",
+			})
 		}
 
 		if strings.Contains(string(dat), `"@opentelemetry/api"`) {
-			utils.AddSuccessfulCheck(messages, "SDK", "Dependency @opentelemetry/api added on package.json")
+			report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: "@opentelemetry/api", Location: &utils.Location{File: filePath}, Message: "Dependency @opentelemetry/api added on package.json"})
 		} else {
-			utils.AddError(messages, "SDK", "Dependency @opentelemetry/api missing on package.json. Install the dependency with `npm install @opentelemetry/auto-instrumentations-node`")
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "SDK",
+				Subject:     "@opentelemetry/api",
+				Location:    &utils.Location{File: filePath},
+				Message:     "Dependency @opentelemetry/api missing on package.json. Install the dependency with `npm install @opentelemetry/auto-instrumentations-node`",
+				Remediation: "Run `npm install @opentelemetry/api`",
+			})
 		}
 	}
 }
 
 func checkJSCodeBasedInstrumentation(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	packageJsonPath string,
 	instrumentationFile string,
 ) {
-	if os.Getenv("NODE_OPTIONS") == "--require @opentelemetry/auto-instrumentations-node/register" {
-		utils.AddError(messages, "SDK", `The flag "-auto-instrumentation" was not passed to otel-checker, but the value of NODE_OPTIONS is set to require auto-instrumentation. Run "unset NODE_OPTIONS" to remove the requirement that can cause a conflict with manual instrumentations`)
+	if env.Getenv("NODE_OPTIONS") == "--require @opentelemetry/auto-instrumentations-node/register" {
+		report.Add(utils.Finding{
+			Severity:    utils.ERRORS,
+			Component:   "SDK",
+			Subject:     "NODE_OPTIONS",
+			Message:     `The flag "-auto-instrumentation" was not passed to otel-checker, but the value of NODE_OPTIONS is set to require auto-instrumentation. Run "unset NODE_OPTIONS" to remove the requirement that can cause a conflict with manual instrumentations`,
+			Remediation: "Run 'unset NODE_OPTIONS'",
+		})
 	}
 
 	// Dependencies for auto instrumentation on package.json
 	filePath := packageJsonPath + "package.json"
-	packageJsonContent, err := os.ReadFile(filePath)
+	packageJsonContent, err := env.ReadFile(filePath)
 	if err != nil {
-		utils.AddError(messages, "SDK", fmt.Sprintf("Could not check file %s: %s", filePath, err))
+		report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Location: &utils.Location{File: filePath}, Message: fmt.Sprintf("Could not check file %s: %s", filePath, err)})
 	} else {
 		if strings.Contains(string(packageJsonContent), `"@opentelemetry/api"`) {
-			utils.AddSuccessfulCheck(messages, "SDK", "Dependency @opentelemetry/api added on package.json")
+			report.Add(utils.Finding{Severity: utils.CHECKS, Component: "SDK", Subject: "@opentelemetry/api", Location: &utils.Location{File: filePath}, Message: "Dependency @opentelemetry/api added on package.json"})
 		} else {
-			utils.AddError(messages, "SDK", "Dependency @opentelemetry/api missing on package.json")
+			report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Subject: "@opentelemetry/api", Location: &utils.Location{File: filePath}, Message: "Dependency @opentelemetry/api missing on package.json"})
 		}
 
 		if strings.Contains(string(packageJsonContent), `"@opentelemetry/exporter-trace-otlp-proto"`) {
-			utils.AddError(messages, "SDK", `Dependency @opentelemetry/exporter-trace-otlp-proto added on package.json, which is not supported by Grafana. Switch the dependency to "@opentelemetry/exporter-trace-otlp-http" instead`)
+			report.Add(utils.Finding{
+				Severity:    utils.ERRORS,
+				Component:   "SDK",
+				Subject:     "@opentelemetry/exporter-trace-otlp-proto",
+				Location:    &utils.Location{File: filePath},
+				Message:     `Dependency @opentelemetry/exporter-trace-otlp-proto added on package.json, which is not supported by Grafana. Switch the dependency to "@opentelemetry/exporter-trace-otlp-http" instead`,
+				Remediation: "Run `npm uninstall @opentelemetry/exporter-trace-otlp-proto && npm install @opentelemetry/exporter-trace-otlp-http`",
+			})
 		}
 	}
 
 	// Check Exporter
-	instrumentationFileContent, err := os.ReadFile(instrumentationFile)
+	instrumentationFileContent, err := env.ReadFile(instrumentationFile)
 	if err != nil {
-		utils.AddError(messages, "SDK", fmt.Sprintf("Could not check file %s: %s", instrumentationFile, err))
+		report.Add(utils.Finding{Severity: utils.ERRORS, Component: "SDK", Location: &utils.Location{File: instrumentationFile}, Message: fmt.Sprintf("Could not check file %s: %s", instrumentationFile, err)})
 	} else {
 		if strings.Contains(string(instrumentationFileContent), "ConsoleSpanExporter") {
-			utils.AddWarning(messages, "SDK", "Instrumentation file is using ConsoleSpanExporter. This exporter is useful during debugging, but replace with OTLPTraceExporter to send to Grafana Cloud")
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "SDK",
+				Subject:   "ConsoleSpanExporter",
+				Location:  &utils.Location{File: instrumentationFile},
+				Message:   "Instrumentation file is using ConsoleSpanExporter. This exporter is useful during debugging, but replace with OTLPTraceExporter to send to Grafana Cloud",
+			})
 		}
 		if strings.Contains(string(instrumentationFileContent), "ConsoleMetricExporter") {
-			utils.AddWarning(messages, "SDK", "Instrumentation file is using ConsoleMetricExporter. This exporter is useful during debugging, but replace with OTLPMetricExporter to send to Grafana Cloud")
+			report.Add(utils.Finding{
+				Severity:  utils.WARNINGS,
+				Component: "SDK",
+				Subject:   "ConsoleMetricExporter",
+				Location:  &utils.Location{File: instrumentationFile},
+				Message:   "Instrumentation file is using ConsoleMetricExporter. This exporter is useful during debugging, but replace with OTLPMetricExporter to send to Grafana Cloud",
+			})
 		}
 	}
 }
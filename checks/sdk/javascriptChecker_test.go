@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/checks/utils"
+	"otel-checker/checks/utils/utilstest"
+)
+
+func TestCheckNodeVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		err          error
+		wantSeverity utils.Severity
+	}{
+		{name: "recent version", output: "v20.11.1\n", wantSeverity: utils.CHECKS},
+		{name: "minimum version", output: "v16.0.0\n", wantSeverity: utils.CHECKS},
+		{name: "old version", output: "v14.21.3\n", wantSeverity: utils.ERRORS},
+		{name: "node not installed", err: errors.New(`exec: "node": executable file not found in $PATH`), wantSeverity: utils.ERRORS},
+		{name: "unexpected output", output: "", wantSeverity: utils.ERRORS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			env := utils.Env{
+				Context: context.Background(),
+				RunCommand: func(name string, args ...string) ([]byte, error) {
+					if name != "node" || !slices.Equal(args, []string{"-v"}) {
+						t.Errorf("ran %s %v, want node -v", name, args)
+					}
+					return []byte(tt.output), tt.err
+				},
+			}
+			checkNodeVersion(report, env)
+
+			if len(report.Findings) != 1 || report.Findings[0].Severity != tt.wantSeverity {
+				t.Errorf("findings = %v, want a single finding with severity %s", report.Findings, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestCheckJSAutoInstrumentation(t *testing.T) {
+	tests := []struct {
+		name            string
+		env             map[string]string
+		files           fstest.MapFS
+		packageJsonPath string
+		wantErrors      []string
+		wantWarnings    []string
+	}{
+		{
+			name: "valid",
+			env:  map[string]string{"NODE_OPTIONS": "--require @opentelemetry/auto-instrumentations-node/register"},
+			files: fstest.MapFS{
+				"app/package.json": {Data: []byte(`{"dependencies": {"@opentelemetry/api": "^1.8.0", "@opentelemetry/auto-instrumentations-node": "^0.46.0"}}`)},
+			},
+			packageJsonPath: "app/",
+		},
+		{
+			name: "missing dependencies and NODE_OPTIONS",
+			files: fstest.MapFS{
+				"package.json": {Data: []byte(`{"dependencies": {"express": "^4.19.0"}}`)},
+			},
+			wantErrors:   []string{"@opentelemetry/auto-instrumentations-node", "@opentelemetry/api"},
+			wantWarnings: []string{"NODE_OPTIONS"},
+		},
+		{
+			name:         "missing package.json",
+			files:        fstest.MapFS{},
+			wantErrors:   []string{""},
+			wantWarnings: []string{"NODE_OPTIONS"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(tt.env), FS: tt.files}
+			checkJSAutoInstrumentation(report, env, tt.packageJsonPath)
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestCheckJSCodeBasedInstrumentation(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		files        fstest.MapFS
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "valid",
+			files: fstest.MapFS{
+				"package.json":                {Data: []byte(`{"dependencies": {"@opentelemetry/api": "^1.8.0"}}`)},
+				"src/inst/instrumentation.js": {Data: []byte(`const exporter = new OTLPTraceExporter();`)},
+			},
+		},
+		{
+			name: "console exporters",
+			files: fstest.MapFS{
+				"package.json":                {Data: []byte(`{"dependencies": {"@opentelemetry/api": "^1.8.0"}}`)},
+				"src/inst/instrumentation.js": {Data: []byte(`new ConsoleSpanExporter(); new ConsoleMetricExporter();`)},
+			},
+			wantWarnings: []string{"ConsoleSpanExporter", "ConsoleMetricExporter"},
+		},
+		{
+			name: "proto exporter and auto-instrumentation NODE_OPTIONS",
+			env:  map[string]string{"NODE_OPTIONS": "--require @opentelemetry/auto-instrumentations-node/register"},
+			files: fstest.MapFS{
+				"package.json":                {Data: []byte(`{"dependencies": {"@opentelemetry/api": "^1.8.0", "@opentelemetry/exporter-trace-otlp-proto": "^0.51.0"}}`)},
+				"src/inst/instrumentation.js": {Data: []byte(``)},
+			},
+			wantErrors: []string{"NODE_OPTIONS", "@opentelemetry/exporter-trace-otlp-proto"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := utils.NewReport()
+			env := utils.Env{Context: context.Background(), Getenv: utils.MapGetenv(tt.env), FS: tt.files}
+			checkJSCodeBasedInstrumentation(report, env, "", "src/inst/instrumentation.js")
+
+			if got := utilstest.Subjects(report, utils.ERRORS); !slices.Equal(got, tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.wantErrors)
+			}
+			if got := utilstest.Subjects(report, utils.WARNINGS); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
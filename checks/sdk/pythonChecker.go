@@ -1,19 +1,22 @@
 package sdk
 
+import "otel-checker/checks/utils"
+
 func CheckPythonSetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	autoInstrumentation bool,
 ) {
-	checkPythonVersion(messages)
+	checkPythonVersion(report)
 	if autoInstrumentation {
-		checkPythonAutoInstrumentation(messages)
+		checkPythonAutoInstrumentation(report)
 	} else {
-		checkPythonCodeBasedInstrumentation(messages)
+		checkPythonCodeBasedInstrumentation(report)
 	}
 }
 
-func checkPythonVersion(messages *map[string][]string) {}
+func checkPythonVersion(report *utils.Report) {}
 
-func checkPythonAutoInstrumentation(messages *map[string][]string) {}
+func checkPythonAutoInstrumentation(report *utils.Report) {}
 
-func checkPythonCodeBasedInstrumentation(messages *map[string][]string) {}
+func checkPythonCodeBasedInstrumentation(report *utils.Report) {}
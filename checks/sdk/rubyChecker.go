@@ -1,16 +1,19 @@
 package sdk
 
+import "otel-checker/checks/utils"
+
 func CheckRubySetup(
-	messages *map[string][]string,
+	report *utils.Report,
+	env utils.Env,
 	autoInstrumentation bool,
 ) {
 	if autoInstrumentation {
-		checkRubyAutoInstrumentation(messages)
+		checkRubyAutoInstrumentation(report)
 	} else {
-		checkRubyCodeBasedInstrumentation(messages)
+		checkRubyCodeBasedInstrumentation(report)
 	}
 }
 
-func checkRubyAutoInstrumentation(messages *map[string][]string) {}
+func checkRubyAutoInstrumentation(report *utils.Report) {}
 
-func checkRubyCodeBasedInstrumentation(messages *map[string][]string) {}
+func checkRubyCodeBasedInstrumentation(report *utils.Report) {}
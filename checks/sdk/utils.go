@@ -1,22 +1,24 @@
 package sdk
 
-func CheckSDKSetup(
-	messages *map[string][]string,
-	language string,
-	autoInstrumentation bool,
-	packageJsonPath string,
-	instrumentationFile string,
-) {
-	switch language {
-	case "dotnet":
-		CheckDotNetSetup(messages, autoInstrumentation)
-	case "go":
-		CheckGoSetup(messages, autoInstrumentation)
-	case "java":
-		CheckJavaSetup(messages, autoInstrumentation)
-	case "js":
-		CheckJSSetup(messages, autoInstrumentation, packageJsonPath, instrumentationFile)
-	case "python":
-		CheckPythonSetup(messages, autoInstrumentation)
-	}
+import "otel-checker/checks/utils"
+
+func init() {
+	utils.RegisterCheck(utils.NewCheck("sdk.dotnet", "sdk", []string{"dotnet"}, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckDotNetSetup(report, env, commands.AutoInstrumentation)
+	}))
+	utils.RegisterCheck(utils.NewCheck("sdk.go", "sdk", []string{"go"}, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckGoSetup(report, env, commands.AutoInstrumentation)
+	}))
+	utils.RegisterCheck(utils.NewCheck("sdk.java", "sdk", []string{"java"}, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckJavaSetup(report, env, commands.AutoInstrumentation, commands.BuildFilePath)
+	}))
+	utils.RegisterCheck(utils.NewCheck("sdk.js", "sdk", []string{"js"}, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckJSSetup(report, env, commands.AutoInstrumentation, commands.PackageJsonPath, commands.InstrumentationFile)
+	}))
+	utils.RegisterCheck(utils.NewCheck("sdk.python", "sdk", []string{"python"}, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckPythonSetup(report, env, commands.AutoInstrumentation)
+	}))
+	utils.RegisterCheck(utils.NewCheck("sdk.ruby", "sdk", []string{"ruby"}, func(report *utils.Report, env utils.Env, commands utils.Commands) {
+		CheckRubySetup(report, env, commands.AutoInstrumentation)
+	}))
 }
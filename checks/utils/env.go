@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Env is everything the checks read from outside of the commands, so they can
+// be run against something other than the current process and host.
+type Env struct {
+	// Context stops the checks when it is done.
+	Context context.Context
+	// Getenv returns the value of an environment variable, or "" when it is not set.
+	Getenv func(key string) string
+	// FS is the filesystem files are read from. When nil, files are read from
+	// the host. Paths are resolved against the root of FS, with any leading "/" removed.
+	FS fs.FS
+	// RunCommand runs a program, such as "node -v", and returns its output. When
+	// nil, programs are run on the host, and killed when Context is done. See
+	// Output and CombinedOutput for the output it returns.
+	RunCommand func(name string, args ...string) ([]byte, error)
+	// HTTPClient is used by the checks that make requests, such as the credentials check.
+	HTTPClient *http.Client
+}
+
+// Time the requests of DefaultEnv have to complete.
+const httpTimeout = 30 * time.Second
+
+// DefaultEnv returns the environment of the current process and host.
+func DefaultEnv() Env {
+	return Env{
+		Context:    context.Background(),
+		Getenv:     os.Getenv,
+		HTTPClient: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Output runs a program, such as "node -v", and returns its standard output.
+func (e Env) Output(name string, args ...string) ([]byte, error) {
+	if e.RunCommand != nil {
+		return e.RunCommand(name, args...)
+	}
+	return e.command(name, args...).Output()
+}
+
+// CombinedOutput runs a program printing to its standard error, such as
+// "java -version", and returns its standard output and standard error. When
+// RunCommand is set, it must return the standard error of such programs.
+func (e Env) CombinedOutput(name string, args ...string) ([]byte, error) {
+	if e.RunCommand != nil {
+		return e.RunCommand(name, args...)
+	}
+	return e.command(name, args...).CombinedOutput()
+}
+
+func (e Env) command(name string, args ...string) *exec.Cmd {
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// MapGetenv returns a Getenv function reading the environment variables from vars.
+func MapGetenv(vars map[string]string) func(key string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+// ReadFile reads a file from the filesystem of the environment.
+func (e Env) ReadFile(name string) ([]byte, error) {
+	if e.FS == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(e.FS, fsPath(name))
+}
+
+// ReadDir reads a directory from the filesystem of the environment, returning its entries sorted by name.
+func (e Env) ReadDir(name string) ([]fs.DirEntry, error) {
+	if e.FS == nil {
+		return os.ReadDir(name)
+	}
+	return fs.ReadDir(e.FS, fsPath(name))
+}
+
+// Stat returns the description of a file of the filesystem of the environment, without reading it.
+func (e Env) Stat(name string) (fs.FileInfo, error) {
+	if e.FS == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(e.FS, fsPath(name))
+}
+
+// Readlink returns the target of a symbolic link of the filesystem of the
+// environment. In FS, a symbolic link is a file with the fs.ModeSymlink mode
+// and its target as content, as in fstest.MapFS.
+func (e Env) Readlink(name string) (string, error) {
+	if e.FS == nil {
+		return os.Readlink(name)
+	}
+	if fsys, ok := e.FS.(interface {
+		ReadLink(name string) (string, error)
+	}); ok {
+		return fsys.ReadLink(fsPath(name))
+	}
+	info, err := fs.Stat(e.FS, fsPath(name))
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	data, err := fs.ReadFile(e.FS, fsPath(name))
+	return string(data), err
+}
+
+// WriteFile writes a file to the filesystem of the environment, creating it if
+// needed. FS can only be written to when it has a WriteFile method with the
+// signature of os.WriteFile.
+func (e Env) WriteFile(name string, data []byte) error {
+	if e.FS == nil {
+		return os.WriteFile(name, data, 0o644)
+	}
+	if fsys, ok := e.FS.(interface {
+		WriteFile(name string, data []byte, perm fs.FileMode) error
+	}); ok {
+		return fsys.WriteFile(fsPath(name), data, 0o644)
+	}
+	return &fs.PathError{Op: "write", Path: name, Err: fs.ErrPermission}
+}
+
+// fsPath converts a path passed on the command line into a path valid for fs.FS.
+func fsPath(name string) string {
+	p := strings.TrimLeft(path.Clean(filepath.ToSlash(name)), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEnvReadFile(t *testing.T) {
+	env := Env{FS: fstest.MapFS{"etc/otelcol/config.yaml": {Data: []byte("receivers:")}}}
+	for _, name := range []string{"etc/otelcol/config.yaml", "/etc/otelcol/config.yaml", "./etc/otelcol/config.yaml", "etc/otelcol/../otelcol/config.yaml"} {
+		data, err := env.ReadFile(name)
+		if err != nil || string(data) != "receivers:" {
+			t.Errorf("ReadFile(%q) = %q, %v", name, data, err)
+		}
+	}
+	if _, err := env.ReadFile("config.yaml"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestEnvReadDir(t *testing.T) {
+	env := Env{FS: fstest.MapFS{"etc/alloy/config.alloy": {}, "etc/alloy/extra.alloy": {}}}
+	entries, err := env.ReadDir("/etc/alloy")
+	if err != nil || len(entries) != 2 || entries[0].Name() != "config.alloy" {
+		t.Errorf("ReadDir() = %v, %v", entries, err)
+	}
+	if _, err := env.ReadDir("etc/alloy/config.alloy"); err == nil {
+		t.Error("expected an error for a file")
+	}
+}
+
+func TestEnvStat(t *testing.T) {
+	env := Env{FS: fstest.MapFS{"sys/kernel/btf/vmlinux": {Data: []byte("BTF")}}}
+	info, err := env.Stat("/sys/kernel/btf/vmlinux")
+	if err != nil || info.Size() != 3 {
+		t.Errorf("Stat() = %v, %v", info, err)
+	}
+	if _, err := env.Stat("/sys/kernel/btf/missing"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestEnvReadlink(t *testing.T) {
+	env := Env{FS: fstest.MapFS{
+		"proc/1/exe":  {Data: []byte("/usr/bin/java"), Mode: fs.ModeSymlink},
+		"proc/1/fd/3": {Data: []byte("socket:[1234]"), Mode: fs.ModeSymlink},
+		"proc/1/comm": {Data: []byte("java")},
+	}}
+	for name, want := range map[string]string{"/proc/1/exe": "/usr/bin/java", "/proc/1/fd/3": "socket:[1234]"} {
+		if got, err := env.Readlink(name); err != nil || got != want {
+			t.Errorf("Readlink(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := env.Readlink("/proc/1/comm"); err == nil {
+		t.Error("expected an error for a file that is not a link")
+	}
+}
+
+func TestEnvWriteFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.alloy")
+	if err := (Env{}).WriteFile(name, []byte("logging {}")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(name); err != nil || string(data) != "logging {}" {
+		t.Errorf("written file = %q, %v", data, err)
+	}
+	if err := (Env{FS: fstest.MapFS{}}).WriteFile("config.alloy", nil); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("WriteFile on a read-only FS = %v, want %v", err, fs.ErrPermission)
+	}
+}
+
+func TestEnvOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	env := Env{Context: context.Background()}
+	if got, err := env.Output("sh", "-c", "echo out; echo err >&2"); err != nil || string(got) != "out\n" {
+		t.Errorf("Output() = %q, %v, want only the standard output", got, err)
+	}
+	if got, err := env.CombinedOutput("sh", "-c", "echo err >&2"); err != nil || string(got) != "err\n" {
+		t.Errorf("CombinedOutput() = %q, %v, want the standard error", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (Env{Context: ctx}).Output("sh", "-c", "sleep 10"); err == nil {
+		t.Error("expected an error when the context is done")
+	}
+
+	env.RunCommand = func(name string, args ...string) ([]byte, error) {
+		return []byte("v20.11.0\n"), nil
+	}
+	if got, err := env.Output("node", "-v"); err != nil || string(got) != "v20.11.0\n" {
+		t.Errorf("Output() = %q, %v, want the output of RunCommand", got, err)
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by ParseArgs and Commands.Validate.
+var ErrMissingLanguage = errors.New("You must pass a language used for your instrumentation, such as -language=js")
+var ErrMissingInstrumentationFile = errors.New(`When auto-instrumentation is not being used, a instrumentation file is required. Add "-auto-instrumentation" or "-instrumentation-file=path/to/file/file.js"`)
+var ErrWatchWithoutServe = errors.New(`The flag "-watch" can only be used together with "-serve"`)
+var ErrAlloyConvertComponents = errors.New(`The flags "-alloy-convert" and "-alloy-convert-output" convert the collector config to Alloy, so they need the alloy and collector components. E.g. -components="collector,alloy"`)
+
+// ErrMissingComponents is returned when no component is passed. The list of
+// possible values is only known once all checks are registered.
+var ErrMissingComponents = missingComponentsError{}
+
+type missingComponentsError struct{}
+
+func (missingComponentsError) Error() string {
+	return fmt.Sprintf(`Component flag required. Possible values: %s. E.g. -components="sdk,collector"`, strings.Join(Components(), ", "))
+}
+
+type UnknownLanguageError struct {
+	Language string
+}
+
+func (e *UnknownLanguageError) Error() string {
+	return fmt.Sprintf("Language %s not supported. Possible values: %s", e.Language, strings.Join(Languages(), ", "))
+}
+
+type UnknownComponentError struct {
+	Component string
+}
+
+func (e *UnknownComponentError) Error() string {
+	return fmt.Sprintf(`Component %s not supported. Possible values: %s. E.g. -components="sdk,collector"`, e.Component, strings.Join(Components(), ", "))
+}
+
+// UnsupportedValueError is returned when a flag with a fixed list of values has an unexpected value.
+type UnsupportedValueError struct {
+	Flag           string
+	Value          string
+	PossibleValues []string
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return fmt.Sprintf("Value %s not supported by -%s. Possible values: %s", e.Value, e.Flag, strings.Join(e.PossibleValues, ", "))
+}
@@ -0,0 +1,25 @@
+package utils
+
+// Exit codes of otel-checker when the web server is not started.
+const EXIT_OK = 0
+const EXIT_ERRORS = 1
+const EXIT_WARNINGS = 2
+const EXIT_FAILURE = 3
+
+const FAIL_ON_ERROR = "error"
+const FAIL_ON_WARNING = "warning"
+
+// FailOnValues lists the values accepted by -fail-on.
+var FailOnValues = []string{FAIL_ON_ERROR, FAIL_ON_WARNING}
+
+// ExitCode returns the exit code for the report. Warnings only change the exit
+// code when failOn is FAIL_ON_WARNING.
+func ExitCode(report *Report, failOn string) int {
+	if len(report.BySeverity(ERRORS)) > 0 {
+		return EXIT_ERRORS
+	}
+	if failOn == FAIL_ON_WARNING && len(report.BySeverity(WARNINGS)) > 0 {
+		return EXIT_WARNINGS
+	}
+	return EXIT_OK
+}
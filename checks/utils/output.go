@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const OUTPUT_TEXT = "text"
+const OUTPUT_JSON = "json"
+const OUTPUT_NDJSON = "ndjson"
+
+// OutputFormats lists the values accepted by -output.
+var OutputFormats = []string{OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_NDJSON}
+
+// SchemaVersion is the version of the JSON and NDJSON output. It must be
+// increased whenever a field is removed or changes meaning.
+const SchemaVersion = 1
+
+type jsonSummary struct {
+	Checks   int `json:"checks"`
+	Warnings int `json:"warnings"`
+	Errors   int `json:"errors"`
+}
+
+type jsonOutput struct {
+	Version    int         `json:"version"`
+	Language   string      `json:"language"`
+	Components []string    `json:"components"`
+	Summary    jsonSummary `json:"summary"`
+	Findings   []Finding   `json:"findings"`
+}
+
+type ndjsonFinding struct {
+	Version int `json:"version"`
+	Finding
+}
+
+// PrintReport writes the report to w in the format selected with -output.
+func PrintReport(w io.Writer, report *Report, commands Commands) error {
+	switch commands.Output {
+	case OUTPUT_JSON:
+		return PrintJSON(w, report, commands)
+	case OUTPUT_NDJSON:
+		return PrintNDJSON(w, report)
+	case OUTPUT_TEXT, "":
+		PrintResults(report)
+		return nil
+	}
+	return fmt.Errorf("output %s not supported", commands.Output)
+}
+
+// PrintJSON writes the report as a single JSON document.
+func PrintJSON(w io.Writer, report *Report, commands Commands) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonOutput{
+		Version:    SchemaVersion,
+		Language:   commands.Language,
+		Components: commands.Components,
+		Summary: jsonSummary{
+			Checks:   len(report.BySeverity(CHECKS)),
+			Warnings: len(report.BySeverity(WARNINGS)),
+			Errors:   len(report.BySeverity(ERRORS)),
+		},
+		Findings: report.Findings,
+	})
+}
+
+// PrintNDJSON writes one JSON document per finding, each on its own line.
+func PrintNDJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, f := range report.Findings {
+		if err := encoder.Encode(ndjsonFinding{Version: SchemaVersion, Finding: f}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
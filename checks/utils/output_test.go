@@ -0,0 +1,118 @@
+package utils_test
+
+import (
+	"bytes"
+	"testing"
+
+	"otel-checker/checks/utils"
+)
+
+// outputReport has a finding with every field set, and findings without the optional ones.
+func outputReport() *utils.Report {
+	report := utils.NewReport()
+	report.Add(utils.Finding{
+		CheckID:     "collector.auth",
+		Severity:    utils.ERRORS,
+		Component:   "Collector",
+		Subject:     "extensions.basicauth",
+		Location:    &utils.Location{File: "config.yaml", Line: 12},
+		Message:     "The password of extension basicauth is empty",
+		Remediation: "Set client_auth > password to a Grafana Cloud token",
+		DocURL:      "https://grafana.com/docs/<collector>",
+	})
+	report.Add(utils.Finding{CheckID: "collector", Severity: utils.WARNINGS, Component: "Collector", Location: &utils.Location{File: "config.yaml"}, Message: "Warning"})
+	report.Add(utils.Finding{CheckID: "sdk", Severity: utils.CHECKS, Component: "SDK", Message: "Check"})
+	return report
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := utils.PrintJSON(&buf, outputReport(), utils.Commands{Language: "go", Components: []string{"collector", "sdk"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "version": 1,
+  "language": "go",
+  "components": [
+    "collector",
+    "sdk"
+  ],
+  "summary": {
+    "checks": 1,
+    "warnings": 1,
+    "errors": 1
+  },
+  "findings": [
+    {
+      "check_id": "collector.auth",
+      "severity": "errors",
+      "component": "Collector",
+      "subject": "extensions.basicauth",
+      "location": {
+        "file": "config.yaml",
+        "line": 12
+      },
+      "message": "The password of extension basicauth is empty",
+      "remediation": "Set client_auth > password to a Grafana Cloud token",
+      "doc_url": "https://grafana.com/docs/<collector>"
+    },
+    {
+      "check_id": "collector",
+      "severity": "warnings",
+      "component": "Collector",
+      "location": {
+        "file": "config.yaml"
+      },
+      "message": "Warning"
+    },
+    {
+      "check_id": "sdk",
+      "severity": "checks",
+      "component": "SDK",
+      "message": "Check"
+    }
+  ]
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrintJSONWithoutFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := utils.PrintJSON(&buf, utils.NewReport(), utils.Commands{Language: "js", Components: []string{"sdk"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "version": 1,
+  "language": "js",
+  "components": [
+    "sdk"
+  ],
+  "summary": {
+    "checks": 0,
+    "warnings": 0,
+    "errors": 0
+  },
+  "findings": []
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrintNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := utils.PrintNDJSON(&buf, outputReport()); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"version":1,"check_id":"collector.auth","severity":"errors","component":"Collector","subject":"extensions.basicauth","location":{"file":"config.yaml","line":12},"message":"The password of extension basicauth is empty","remediation":"Set client_auth > password to a Grafana Cloud token","doc_url":"https://grafana.com/docs/<collector>"}
+{"version":1,"check_id":"collector","severity":"warnings","component":"Collector","location":{"file":"config.yaml"},"message":"Warning"}
+{"version":1,"check_id":"sdk","severity":"checks","component":"SDK","message":"Check"}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
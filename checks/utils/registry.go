@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Check is a single unit of validation that can be registered with RegisterCheck.
+// A check with an empty Component always runs, regardless of the components
+// passed on the command line. A check with no Languages applies to every language.
+type Check interface {
+	ID() string
+	Component() string
+	Languages() []string
+	Run(report *Report, env Env, commands Commands)
+}
+
+type check struct {
+	id        string
+	component string
+	languages []string
+	run       func(report *Report, env Env, commands Commands)
+}
+
+func (c check) ID() string          { return c.id }
+func (c check) Component() string   { return c.component }
+func (c check) Languages() []string { return c.languages }
+func (c check) Run(report *Report, env Env, commands Commands) {
+	c.run(report, env, commands)
+}
+
+// NewCheck creates a Check from a function, for checks that don't need their own type.
+func NewCheck(
+	id string,
+	component string,
+	languages []string,
+	run func(report *Report, env Env, commands Commands),
+) Check {
+	return check{id: id, component: component, languages: languages, run: run}
+}
+
+var registry []Check
+
+// RegisterCheck adds a check to the registry. It is meant to be called from
+// the init function of the package implementing the check, and panics if a
+// check with the same ID was already registered.
+func RegisterCheck(c Check) {
+	for _, r := range registry {
+		if r.ID() == c.ID() {
+			panic(fmt.Sprintf("check %s registered twice", c.ID()))
+		}
+	}
+	registry = append(registry, c)
+}
+
+// Checks returns all registered checks, in registration order.
+func Checks() []Check {
+	return slices.Clone(registry)
+}
+
+// Components returns the sorted list of components that can be passed to -components.
+func Components() []string {
+	var components []string
+	for _, c := range registry {
+		if c.Component() != "" && !slices.Contains(components, c.Component()) {
+			components = append(components, c.Component())
+		}
+	}
+	sort.Strings(components)
+	return components
+}
+
+// Languages returns the sorted list of languages that can be passed to -language.
+func Languages() []string {
+	var languages []string
+	for _, c := range registry {
+		for _, l := range c.Languages() {
+			if !slices.Contains(languages, l) {
+				languages = append(languages, l)
+			}
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// RunChecks runs every registered check that applies to the language and components in commands.
+// Checks that always run are executed first, followed by the checks of each component in the
+// order the components were passed. It stops and returns an error when the context of env is done.
+func RunChecks(report *Report, env Env, commands Commands) error {
+	defer func() { report.checkID = "" }()
+	for _, component := range append([]string{""}, commands.Components...) {
+		for _, c := range registry {
+			if c.Component() != component {
+				continue
+			}
+			if len(c.Languages()) > 0 && !slices.Contains(c.Languages(), commands.Language) {
+				continue
+			}
+			if err := env.Context.Err(); err != nil {
+				return err
+			}
+			report.checkID = c.ID()
+			c.Run(report, env, commands)
+		}
+	}
+	return nil
+}
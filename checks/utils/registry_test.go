@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// withChecks replaces the registry with checks for the duration of the test.
+func withChecks(t *testing.T, checks ...Check) {
+	saved := registry
+	registry = nil
+	t.Cleanup(func() { registry = saved })
+	for _, c := range checks {
+		RegisterCheck(c)
+	}
+}
+
+// addFinding returns a check adding a finding with subject as its subject.
+func addFinding(id string, component string, languages []string, subject string) Check {
+	return NewCheck(id, component, languages, func(report *Report, env Env, commands Commands) {
+		report.Add(Finding{Severity: CHECKS, Component: component, Subject: subject})
+	})
+}
+
+func TestRegisterCheck(t *testing.T) {
+	withChecks(t,
+		addFinding("sdk", "sdk", []string{"js"}, ""),
+		addFinding("collector", "collector", nil, ""),
+		addFinding("alloy", "alloy", nil, ""),
+		addFinding("grafana", "", []string{"go", "java"}, ""),
+	)
+
+	var ids []string
+	for _, c := range Checks() {
+		ids = append(ids, c.ID())
+	}
+	if want := []string{"sdk", "collector", "alloy", "grafana"}; !slices.Equal(ids, want) {
+		t.Errorf("checks = %v, want %v", ids, want)
+	}
+	if got, want := Components(), []string{"alloy", "collector", "sdk"}; !slices.Equal(got, want) {
+		t.Errorf("components = %v, want %v", got, want)
+	}
+	if got, want := Languages(), []string{"go", "java", "js"}; !slices.Equal(got, want) {
+		t.Errorf("languages = %v, want %v", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic when registering a check ID twice")
+		}
+	}()
+	RegisterCheck(addFinding("sdk", "collector", nil, ""))
+}
+
+func TestRunChecks(t *testing.T) {
+	withChecks(t,
+		addFinding("sdk.js", "sdk", []string{"js"}, "package.json"),
+		addFinding("sdk.go", "sdk", []string{"go"}, "go.mod"),
+		addFinding("collector", "collector", nil, "config.yaml"),
+		NewCheck("collector.auth", "collector", nil, func(report *Report, env Env, commands Commands) {
+			report.Add(Finding{CheckID: "collector.auth.basic", Severity: ERRORS, Component: "collector", Subject: "basicauth"})
+		}),
+		addFinding("beyla", "beyla", nil, "beyla-config.yml"),
+		addFinding("grafana", "", nil, "GRAFANA_CLOUD_API_KEY"),
+	)
+
+	report := NewReport()
+	env := Env{Context: context.Background()}
+	if err := RunChecks(report, env, Commands{Language: "js", Components: []string{"collector", "sdk"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][2]string
+	for _, f := range report.Findings {
+		got = append(got, [2]string{f.CheckID, f.Subject})
+	}
+	want := [][2]string{
+		{"grafana", "GRAFANA_CLOUD_API_KEY"},
+		{"collector", "config.yaml"},
+		{"collector.auth.basic", "basicauth"},
+		{"sdk.js", "package.json"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	report.Add(Finding{Severity: CHECKS, Component: "sdk"})
+	if id := report.Findings[len(report.Findings)-1].CheckID; id != "" {
+		t.Errorf("check ID of a finding added after RunChecks = %q, want none", id)
+	}
+}
+
+func TestRunChecksCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	withChecks(t,
+		NewCheck("first", "collector", nil, func(report *Report, env Env, commands Commands) {
+			report.Add(Finding{Severity: CHECKS, Component: "collector"})
+			cancel()
+		}),
+		addFinding("second", "collector", nil, ""),
+	)
+
+	report := NewReport()
+	err := RunChecks(report, Env{Context: ctx}, Commands{Language: "go", Components: []string{"collector"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].CheckID != "first" {
+		t.Errorf("findings = %+v, want only the one of the first check", report.Findings)
+	}
+}
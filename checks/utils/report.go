@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+)
+
+type Severity string
+
+const ERRORS Severity = "errors"
+const WARNINGS Severity = "warnings"
+const CHECKS Severity = "checks"
+
+// Severities lists every severity, in the order results are displayed.
+var Severities = []Severity{CHECKS, WARNINGS, ERRORS}
+
+// Location points at the place in a file a finding is about.
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
+func (l Location) String() string {
+	if l.Line > 0 {
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+	return l.File
+}
+
+// Finding is the result of a single verification done by a check.
+type Finding struct {
+	// CheckID is the ID of the check that produced the finding. It is filled in
+	// automatically by RunChecks when left empty.
+	CheckID   string   `json:"check_id"`
+	Severity  Severity `json:"severity"`
+	Component string   `json:"component"`
+	// Subject is what the finding is about, such as an environment variable,
+	// a dependency or a YAML path.
+	Subject     string    `json:"subject,omitempty"`
+	Location    *Location `json:"location,omitempty"`
+	Message     string    `json:"message"`
+	Remediation string    `json:"remediation,omitempty"`
+	DocURL      string    `json:"doc_url,omitempty"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Component, f.Message)
+}
+
+// Report collects the findings of all checks that were executed.
+type Report struct {
+	Findings []Finding `json:"findings"`
+
+	checkID string
+}
+
+func NewReport() *Report {
+	return &Report{Findings: make([]Finding, 0)}
+}
+
+func (r *Report) Add(f Finding) {
+	if f.CheckID == "" {
+		f.CheckID = r.checkID
+	}
+	r.Findings = append(r.Findings, f)
+}
+
+// BySeverity returns the findings with the given severity, in the order they were added.
+func (r *Report) BySeverity(severity Severity) []Finding {
+	findings := make([]Finding, 0)
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Components returns the components that have findings, in the order they were first reported.
+func (r *Report) Components() []string {
+	components := make([]string, 0)
+	for _, f := range r.Findings {
+		if !slices.Contains(components, f.Component) {
+			components = append(components, f.Component)
+		}
+	}
+	return components
+}
+
+// Filter returns the findings of a component with the given severity, in the order they were added.
+func (r *Report) Filter(component string, severity Severity) []Finding {
+	findings := make([]Finding, 0)
+	for _, f := range r.Findings {
+		if f.Component == component && f.Severity == severity {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func AddSuccessfulCheck(report *Report, component string, message string) {
+	report.Add(Finding{Severity: CHECKS, Component: component, Message: message})
+}
+
+func AddWarning(report *Report, component string, message string) {
+	report.Add(Finding{Severity: WARNINGS, Component: component, Message: message})
+}
+
+func AddError(report *Report, component string, message string) {
+	report.Add(Finding{Severity: ERRORS, Component: component, Message: message})
+}
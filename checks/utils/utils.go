@@ -3,123 +3,291 @@ package utils
 import (
 	"flag"
 	"fmt"
-	"os"
+	"io"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/fatih/color"
 )
 
-const ERRORS = "errors"
-const WARNINGS = "warnings"
-const CHECKS = "checks"
-
 type Commands struct {
-	Language            string
-	Components          []string
-	AutoInstrumentation bool
-	InstrumentationFile string
-	PackageJsonPath     string
-	CollectorConfigPath string
+	Language               string
+	Components             []string
+	AutoInstrumentation    bool
+	InstrumentationFile    string
+	PackageJsonPath        string
+	BuildFilePath          string
+	CollectorConfigPath    string
+	CollectorConfigs       []string
+	CollectorBinary        string
+	CollectorBuilderConfig string
+	AlloyConfigPath        string
+	AlloyConvert           bool
+	AlloyConvertOutput     string
+	BeylaConfigPath        string
+	HostRootPath           string
+	Output                 string
+	FailOn                 string
+	Serve                  bool
+	Listen                 string
+	Watch                  bool
 }
 
-func GetArguments() Commands {
-	command := Commands{}
-	args := os.Args[1:]
+// ParseArgs parses the command line arguments, without the program name, and validates them.
+// It returns flag.ErrHelp when -h or -help is passed.
+func ParseArgs(args []string) (Commands, error) {
 	if len(args) < 1 {
-		fmt.Println(color.RedString("You must pass a language used for your instrumentation, such as -language=js"))
-		os.Exit(1)
+		return Commands{}, ErrMissingLanguage
+	}
+
+	flags, commands := newFlagSet()
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return Commands{}, err
+	}
+
+	command := commands()
+	if err := command.Validate(); err != nil {
+		return Commands{}, err
+	}
+	return command, nil
+}
+
+// PrintUsage writes the description of all flags to w.
+func PrintUsage(w io.Writer) {
+	flags, _ := newFlagSet()
+	flags.SetOutput(w)
+	fmt.Fprintln(w, "Usage of otel-checker:")
+	flags.PrintDefaults()
+}
+
+// newFlagSet defines all flags on a new flag set. The returned function
+// converts the parsed values into Commands.
+func newFlagSet() (*flag.FlagSet, func() Commands) {
+	flags := flag.NewFlagSet("otel-checker", flag.ContinueOnError)
+
+	possibleLanguages := strings.Join(Languages(), ", ")
+	possibleComponents := strings.Join(Components(), ", ")
+	languageValue := flags.String("language", "", fmt.Sprintf("Language used for instrumentation (required). Possible values: %s", possibleLanguages))
+	componentsString := flags.String("components", "", fmt.Sprintf("Instrumentation components to test, separated by ',' (required). Possible values: %s", possibleComponents))
+	autoInstrumentation := flags.Bool("auto-instrumentation", false, "Provide if your application is using auto instrumentation")
+	instrumentationFile := flags.String("instrumentation-file", "", `Name (including path) to instrumentation file. Required if not using auto-instrumentation. E.g."-instrumentation-file=src/inst/instrumentation.js"`)
+	packageJsonPath := flags.String("package-json-path", "", `Path to package.json file. Required if instrumentation is in JavaScript and the file is not in the same location as the otel-checker is being executed from. E.g. "-package-json-path=src/inst/"`)
+	buildFilePath := flags.String("build-file-path", "", `Path to the build file of the application, pom.xml, build.gradle or build.gradle.kts. Used if instrumentation is in Java. Defaults to the one in the directory otel-checker is being executed from. E.g. "-build-file-path=app/pom.xml"`)
+	collectorConfigPath := flags.String("collector-config-path", "", `Path to collector's config.yaml file. Required if using Collector and the config file is not in the same location as the otel-checker is being executed from. E.g. "-collector-config-path=src/inst/"`)
+	var collectorConfigs []string
+	flags.Func("collector-config", `File or URI of the collector config, as passed to the collector's "--config" flag. Can be repeated, in which case the configs are merged in order. Supports the file:, env:, yaml:, http: and https: providers, and takes precedence over "-collector-config-path". E.g. "-collector-config=config.yaml -collector-config=yaml:exporters::otlphttp::endpoint: http://localhost:4318"`, func(value string) error {
+		collectorConfigs = append(collectorConfigs, value)
+		return nil
+	})
+	collectorBinary := flags.String("collector-binary", "", `Collector binary to check the config against. Its components and version are listed with "<binary> components". E.g. "-collector-binary=/usr/bin/otelcol-contrib"`)
+	collectorBuilderConfig := flags.String("collector-builder-config", "", `Builder config (builder-config.yaml) used to build the collector with ocb, to check the config against when the binary is not available. E.g. "-collector-builder-config=otelcol-builder.yaml"`)
+	alloyConfigPath := flags.String("alloy-config-path", "", `Path to the Alloy config file, or to a directory of .alloy files. Defaults to config.alloy. E.g. "-alloy-config-path=/etc/alloy/config.alloy"`)
+	alloyConvert := flags.Bool("alloy-convert", false, `Report, component by component, whether the collector config can be converted to Alloy. Requires the alloy and collector components`)
+	alloyConvertOutput := flags.String("alloy-convert-output", "", `File to write the collector config converted to Alloy to. Implies "-alloy-convert". E.g. "-alloy-convert-output=config.alloy"`)
+	beylaConfigPath := flags.String("beyla-config-path", "", `Path to the Beyla YAML config file, as passed to Beyla's "-config" flag. Defaults to the value of BEYLA_CONFIG_PATH. E.g. "-beyla-config-path=/etc/beyla/beyla-config.yml"`)
+	hostRootPath := flags.String("host-root", "", `Directory the /proc and /sys of the host are read from, to check the Beyla prerequisites. Defaults to /. E.g. "-host-root=/host" when otel-checker runs in a container with them mounted under /host`)
+	output := flags.String("output", OUTPUT_TEXT, fmt.Sprintf("Format of the results printed on stdout. Possible values: %s. \"-serve\" can only be used with the text output", strings.Join(OutputFormats, ", ")))
+	failOn := flags.String("fail-on", FAIL_ON_ERROR, fmt.Sprintf("Lowest severity that makes otel-checker exit with a non-zero code. Possible values: %s", strings.Join(FailOnValues, ", ")))
+	serve := flags.Bool("serve", false, "Serve the results on a web page after running the checks, until otel-checker is interrupted")
+	listen := flags.String("listen", "127.0.0.1:8080", `Address the web page is served on when using "-serve", in the format host:port. The default address is only reachable from the current host. E.g. "-listen=:9090"`)
+	watch := flags.Bool("watch", false, `Re-run the checks when one of the files being checked changes. Requires "-serve"`)
+
+	return flags, func() Commands {
+		return Commands{
+			Language:               *languageValue,
+			Components:             ParseComponents(*componentsString),
+			AutoInstrumentation:    *autoInstrumentation,
+			InstrumentationFile:    *instrumentationFile,
+			PackageJsonPath:        DirPath(*packageJsonPath),
+			BuildFilePath:          *buildFilePath,
+			CollectorConfigPath:    DirPath(*collectorConfigPath),
+			CollectorConfigs:       collectorConfigs,
+			CollectorBinary:        *collectorBinary,
+			CollectorBuilderConfig: *collectorBuilderConfig,
+			AlloyConfigPath:        *alloyConfigPath,
+			AlloyConvert:           *alloyConvert,
+			AlloyConvertOutput:     *alloyConvertOutput,
+			BeylaConfigPath:        *beylaConfigPath,
+			HostRootPath:           *hostRootPath,
+			Output:                 *output,
+			FailOn:                 *failOn,
+			Serve:                  *serve,
+			Listen:                 *listen,
+			Watch:                  *watch,
+		}
+	}
+}
+
+// Validate returns an error describing the first unsupported value in the commands.
+func (c Commands) Validate() error {
+	if c.Language == "" {
+		return ErrMissingLanguage
+	}
+	if !slices.Contains(Languages(), c.Language) {
+		return &UnknownLanguageError{Language: c.Language}
+	}
+
+	if len(c.Components) == 0 {
+		return ErrMissingComponents
+	}
+	for _, component := range c.Components {
+		if !slices.Contains(Components(), component) {
+			return &UnknownComponentError{Component: component}
+		}
+	}
+
+	if c.InstrumentationFile == "" && !c.AutoInstrumentation {
+		return ErrMissingInstrumentationFile
+	}
+
+	if !slices.Contains(OutputFormats, c.Output) {
+		return &UnsupportedValueError{Flag: "output", Value: c.Output, PossibleValues: OutputFormats}
+	}
+
+	if !slices.Contains(FailOnValues, c.FailOn) {
+		return &UnsupportedValueError{Flag: "fail-on", Value: c.FailOn, PossibleValues: FailOnValues}
 	}
 
-	languageValue := flag.String("language", "", "Language used for instrumentation (required). Possible values: dotnet, go, java, js, python")
-	componentsString := flag.String("components", "", "Instrumentation components to test, separated by ',' (required). Possible values: sdk, collector, beyla, alloy")
-	autoInstrumentation := flag.Bool("auto-instrumentation", false, "Provide if your application is using auto instrumentation")
-	instrumentationFile := flag.String("instrumentation-file", "", `Name (including path) to instrumentation file. Required if not using auto-instrumentation. E.g."-instrumentation-file=src/inst/instrumentation.js"`)
-	packageJsonPath := flag.String("package-json-path", "", `Path to package.json file. Required if instrumentation is in JavaScript and the file is not in the same location as the otel-checker is being executed from. E.g. "-package-json-path=src/inst/"`)
-	collectorConfigPath := flag.String("collector-config-path", "", `Path to collector's config.yaml file. Required if using Collector and the config file is not in the same location as the otel-checker is being executed from. E.g. "-collector-config-path=src/inst/"`)
-	flag.Parse()
+	if c.Serve && c.Output != OUTPUT_TEXT {
+		return fmt.Errorf(`The web page can only be served with the text output. Remove "-serve" or "-output=%s"`, c.Output)
+	}
 
-	possibleLanguages := []string{"dotnet", "go", "java", "js", "python", "ruby"}
-	if !slices.Contains(possibleLanguages, *languageValue) {
-		fmt.Println(color.RedString(fmt.Sprintf("Language %s not supported. Possible values: dotnet, go, java, js, python, ruby", *languageValue)))
-		os.Exit(1)
+	if c.Watch && !c.Serve {
+		return ErrWatchWithoutServe
 	}
 
-	if *componentsString == "" {
-		fmt.Println(color.RedString(`Component flag required. Possible values: sdk, beyla, alloy, collector. E.g. -components="sdk,collector"`))
-		os.Exit(1)
+	if (c.AlloyConvert || c.AlloyConvertOutput != "") && (!slices.Contains(c.Components, "alloy") || !slices.Contains(c.Components, "collector")) {
+		return ErrAlloyConvertComponents
 	}
+	return nil
+}
 
-	possibleComponents := []string{"sdk", "beyla", "alloy", "collector"}
-	components := strings.Split(*componentsString, ",")
-	for _, c := range components {
-		if !slices.Contains(possibleComponents, strings.Trim(c, " ")) {
-			fmt.Println(color.RedString(fmt.Sprintf(`Component %s not supported. Possible values: sdk, collector, beyla, alloy. E.g. -components="sdk,collector"`, c)))
-			os.Exit(1)
+// ParseComponents splits a comma separated list of components.
+func ParseComponents(components string) []string {
+	var parsed []string
+	for _, c := range strings.Split(components, ",") {
+		if c = strings.Trim(c, " "); c != "" {
+			parsed = append(parsed, c)
 		}
 	}
+	return parsed
+}
 
-	if *instrumentationFile == "" && !*autoInstrumentation {
-		fmt.Println(color.RedString(`When auto-instrumentation is not being used, a instrumentation file is required. Add "-auto-instrumentation" or "-instrumentation-file=path/to/file/file.js"`))
-		os.Exit(1)
+// DirPath adds the trailing "/" expected by the checks to a directory path.
+func DirPath(path string) string {
+	if path != "" && !strings.HasSuffix(path, "/") {
+		return path + "/"
 	}
-	if *packageJsonPath != "" && !strings.HasSuffix(*packageJsonPath, "/") {
-		*packageJsonPath = *packageJsonPath + "/"
+	return path
+}
+
+// CollectorConfigSources returns the sources of the collector config, defaulting to config.yaml in CollectorConfigPath.
+func (c Commands) CollectorConfigSources() []string {
+	if len(c.CollectorConfigs) > 0 {
+		return c.CollectorConfigs
 	}
-	if *collectorConfigPath != "" && !strings.HasSuffix(*collectorConfigPath, "/") {
-		*collectorConfigPath = *collectorConfigPath + "/"
+	return []string{c.CollectorConfigPath + "config.yaml"}
+}
+
+// AlloyConfig returns the path of the Alloy config, defaulting to config.alloy.
+func (c Commands) AlloyConfig() string {
+	if c.AlloyConfigPath == "" {
+		return "config.alloy"
 	}
+	return c.AlloyConfigPath
+}
 
-	command.Language = *languageValue
-	command.Components = components
-	command.AutoInstrumentation = *autoInstrumentation
-	command.InstrumentationFile = *instrumentationFile
-	command.PackageJsonPath = *packageJsonPath
-	command.CollectorConfigPath = *collectorConfigPath
-	return command
+// HostRoot returns the directory the /proc and /sys of the host are read from, defaulting to /.
+func (c Commands) HostRoot() string {
+	if c.HostRootPath == "" {
+		return "/"
+	}
+	return c.HostRootPath
 }
 
-func CreateMessagesMap() map[string][]string {
-	messages := make(map[string][]string)
-	messages[CHECKS] = make([]string, 0)
-	messages[WARNINGS] = make([]string, 0)
-	messages[ERRORS] = make([]string, 0)
+var configURIScheme = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]+):`)
 
-	return messages
+// ConfigFile returns the path of a collector config source read from a file,
+// such as "config.yaml" or "file:config.yaml". It returns false for the other
+// providers, such as "env:" or "yaml:".
+func ConfigFile(uri string) (string, bool) {
+	m := configURIScheme.FindStringSubmatch(uri)
+	switch {
+	case m == nil:
+		return uri, true
+	case m[1] == "file":
+		return strings.TrimPrefix(uri, m[0]), true
+	}
+	return "", false
 }
 
-func PrintResults(messages map[string][]string) {
-	if len(messages[CHECKS]) > 0 {
+// InputFiles returns the files read by the checks selected by commands.
+func InputFiles(commands Commands) []string {
+	var files []string
+	if slices.Contains(commands.Components, "collector") {
+		for _, source := range commands.CollectorConfigSources() {
+			if file, ok := ConfigFile(source); ok {
+				files = append(files, file)
+			}
+		}
+		if commands.CollectorBuilderConfig != "" {
+			files = append(files, commands.CollectorBuilderConfig)
+		}
+	}
+	if slices.Contains(commands.Components, "alloy") {
+		files = append(files, commands.AlloyConfig())
+	}
+	if slices.Contains(commands.Components, "beyla") && commands.BeylaConfigPath != "" {
+		files = append(files, commands.BeylaConfigPath)
+	}
+	if slices.Contains(commands.Components, "sdk") && commands.Language == "js" {
+		files = append(files, commands.PackageJsonPath+"package.json")
+	}
+	if slices.Contains(commands.Components, "sdk") && commands.Language == "java" && commands.BuildFilePath != "" {
+		files = append(files, commands.BuildFilePath)
+	}
+	if commands.InstrumentationFile != "" {
+		files = append(files, commands.InstrumentationFile)
+	}
+	return files
+}
+
+func PrintResults(report *Report) {
+	if checks := report.BySeverity(CHECKS); len(checks) > 0 {
 		green := color.New(color.FgGreen)
-		green.Printf("\n%d Successful Check(s)\n", len(messages[CHECKS]))
-		for _, m := range messages[CHECKS] {
-			green.Printf("✔ %s \n", m)
+		green.Printf("\n%d Successful Check(s)\n", len(checks))
+		for _, f := range checks {
+			green.Printf("✔ %s \n", f)
 		}
 	}
-	if len(messages[WARNINGS]) > 0 {
+	if warnings := report.BySeverity(WARNINGS); len(warnings) > 0 {
 		yellow := color.New(color.FgYellow)
-		yellow.Printf("\n%d Warning(s)\n", len(messages[WARNINGS]))
-		for _, m := range messages[WARNINGS] {
-			yellow.Printf("• %s \n", m)
+		yellow.Printf("\n%d Warning(s)\n", len(warnings))
+		for _, f := range warnings {
+			yellow.Printf("• %s \n", f)
+			printDetails(yellow, f)
 		}
 	}
-	if len(messages[ERRORS]) > 0 {
+	if errors := report.BySeverity(ERRORS); len(errors) > 0 {
 		red := color.New(color.FgRed)
-		red.Printf("\n%d Error(s)\n", len(messages[ERRORS]))
-		for _, m := range messages[ERRORS] {
-			red.Printf("✖ %s \n", m)
+		red.Printf("\n%d Error(s)\n", len(errors))
+		for _, f := range errors {
+			red.Printf("✖ %s \n", f)
+			printDetails(red, f)
 		}
 	}
 }
 
-func AddSuccessfulCheck(messages *map[string][]string, component string, message string) {
-	(*messages)[CHECKS] = append((*messages)[CHECKS], fmt.Sprintf(`%s: %s`, component, message))
-}
-
-func AddWarning(messages *map[string][]string, component string, message string) {
-	(*messages)[WARNINGS] = append((*messages)[WARNINGS], fmt.Sprintf(`%s: %s`, component, message))
-}
-
-func AddError(messages *map[string][]string, component string, message string) {
-	(*messages)[ERRORS] = append((*messages)[ERRORS], fmt.Sprintf(`%s: %s`, component, message))
+func printDetails(c *color.Color, f Finding) {
+	if f.Location != nil {
+		c.Printf("    at %s\n", f.Location)
+	}
+	if f.Remediation != "" {
+		c.Printf("    fix: %s\n", f.Remediation)
+	}
+	if f.DocURL != "" {
+		c.Printf("    docs: %s\n", f.DocURL)
+	}
 }
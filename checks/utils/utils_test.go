@@ -0,0 +1,219 @@
+package utils_test
+
+import (
+	"errors"
+	"flag"
+	"slices"
+	"testing"
+
+	_ "otel-checker/checks"
+	"otel-checker/checks/utils"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    utils.Commands
+		wantErr error
+	}{
+		{
+			name: "valid",
+			args: []string{"-language=js", "-components=sdk, collector", "-auto-instrumentation", "-package-json-path=src/inst", "-collector-config-path=otel/"},
+			want: utils.Commands{
+				Language:            "js",
+				Components:          []string{"sdk", "collector"},
+				AutoInstrumentation: true,
+				PackageJsonPath:     "src/inst/",
+				CollectorConfigPath: "otel/",
+				Output:              utils.OUTPUT_TEXT,
+				FailOn:              utils.FAIL_ON_ERROR,
+				Listen:              "127.0.0.1:8080",
+			},
+		},
+		{
+			name: "multiple collector configs",
+			args: []string{"-language=go", "-components=collector,alloy", "-auto-instrumentation", "-collector-config=base.yaml", "-collector-config=env:EXTRA_CONFIG", "-collector-binary=otelcol-contrib", "-alloy-convert"},
+			want: utils.Commands{
+				Language:            "go",
+				Components:          []string{"collector", "alloy"},
+				AutoInstrumentation: true,
+				AlloyConvert:        true,
+				CollectorConfigs:    []string{"base.yaml", "env:EXTRA_CONFIG"},
+				CollectorBinary:     "otelcol-contrib",
+				Output:              utils.OUTPUT_TEXT,
+				FailOn:              utils.FAIL_ON_ERROR,
+				Listen:              "127.0.0.1:8080",
+			},
+		},
+		{name: "no arguments", args: nil, wantErr: utils.ErrMissingLanguage},
+		{name: "help", args: []string{"-h"}, wantErr: flag.ErrHelp},
+		{name: "unknown language", args: []string{"-language=cobol", "-components=sdk"}, wantErr: &utils.UnknownLanguageError{Language: "cobol"}},
+		{name: "missing components", args: []string{"-language=js", "-auto-instrumentation"}, wantErr: utils.ErrMissingComponents},
+		{name: "unknown component", args: []string{"-language=js", "-components=sdk,agent"}, wantErr: &utils.UnknownComponentError{Component: "agent"}},
+		{name: "missing instrumentation file", args: []string{"-language=js", "-components=sdk"}, wantErr: utils.ErrMissingInstrumentationFile},
+		{name: "watch without serve", args: []string{"-language=js", "-components=sdk", "-auto-instrumentation", "-watch"}, wantErr: utils.ErrWatchWithoutServe},
+		{name: "alloy convert without collector", args: []string{"-language=js", "-components=alloy", "-auto-instrumentation", "-alloy-convert-output=config.alloy"}, wantErr: utils.ErrAlloyConvertComponents},
+		{name: "alloy convert without alloy", args: []string{"-language=js", "-components=collector", "-auto-instrumentation", "-alloy-convert"}, wantErr: utils.ErrAlloyConvertComponents},
+		{
+			name:    "unsupported output",
+			args:    []string{"-language=js", "-components=sdk", "-auto-instrumentation", "-output=xml"},
+			wantErr: &utils.UnsupportedValueError{Flag: "output", Value: "xml", PossibleValues: utils.OutputFormats},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := utils.ParseArgs(tt.args)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Language != tt.want.Language ||
+				!slices.Equal(got.Components, tt.want.Components) ||
+				got.AutoInstrumentation != tt.want.AutoInstrumentation ||
+				got.PackageJsonPath != tt.want.PackageJsonPath ||
+				got.CollectorConfigPath != tt.want.CollectorConfigPath ||
+				!slices.Equal(got.CollectorConfigs, tt.want.CollectorConfigs) ||
+				got.CollectorBinary != tt.want.CollectorBinary ||
+				got.AlloyConvert != tt.want.AlloyConvert ||
+				got.Output != tt.want.Output ||
+				got.FailOn != tt.want.FailOn ||
+				got.Listen != tt.want.Listen {
+				t.Errorf("commands = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInputFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands utils.Commands
+		want     []string
+	}{
+		{
+			name:     "collector config path",
+			commands: utils.Commands{Language: "go", Components: []string{"collector"}, CollectorConfigPath: "otel/"},
+			want:     []string{"otel/config.yaml"},
+		},
+		{
+			name: "collector config sources",
+			commands: utils.Commands{Language: "go", Components: []string{"collector"}, CollectorConfigPath: "otel/",
+				CollectorConfigs: []string{"base.yaml", "file:/etc/otel/extra.yaml", "env:EXTRA_CONFIG", "yaml:exporters::debug: {}"}},
+			want: []string{"base.yaml", "/etc/otel/extra.yaml"},
+		},
+		{
+			name:     "default alloy config",
+			commands: utils.Commands{Language: "go", Components: []string{"alloy"}},
+			want:     []string{"config.alloy"},
+		},
+		{
+			name:     "alloy config path",
+			commands: utils.Commands{Language: "go", Components: []string{"alloy"}, AlloyConfigPath: "/etc/alloy/"},
+			want:     []string{"/etc/alloy/"},
+		},
+		{
+			name:     "no beyla config",
+			commands: utils.Commands{Language: "go", Components: []string{"beyla"}},
+		},
+		{
+			name:     "beyla config path",
+			commands: utils.Commands{Language: "go", Components: []string{"beyla"}, BeylaConfigPath: "beyla-config.yml"},
+			want:     []string{"beyla-config.yml"},
+		},
+		{
+			name:     "java build file",
+			commands: utils.Commands{Language: "java", Components: []string{"sdk"}, BuildFilePath: "app/pom.xml"},
+			want:     []string{"app/pom.xml"},
+		},
+		{
+			name:     "build file of another language",
+			commands: utils.Commands{Language: "js", Components: []string{"sdk"}, BuildFilePath: "app/pom.xml"},
+			want:     []string{"package.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.InputFiles(tt.commands); !slices.Equal(got, tt.want) {
+				t.Errorf("input files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrorTypes(t *testing.T) {
+	_, err := utils.ParseArgs([]string{"-language=cobol", "-components=sdk"})
+	var languageErr *utils.UnknownLanguageError
+	if !errors.As(err, &languageErr) || languageErr.Language != "cobol" {
+		t.Errorf("error = %v, want an UnknownLanguageError for cobol", err)
+	}
+
+	_, err = utils.ParseArgs([]string{"-language=js", "-components=agent"})
+	var componentErr *utils.UnknownComponentError
+	if !errors.As(err, &componentErr) || componentErr.Component != "agent" {
+		t.Errorf("error = %v, want an UnknownComponentError for agent", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	withFindings := func(severities ...utils.Severity) *utils.Report {
+		report := utils.NewReport()
+		for _, s := range severities {
+			report.Add(utils.Finding{Severity: s})
+		}
+		return report
+	}
+
+	tests := []struct {
+		name   string
+		report *utils.Report
+		failOn string
+		want   int
+	}{
+		{name: "all passed", report: withFindings(utils.CHECKS), failOn: utils.FAIL_ON_ERROR, want: utils.EXIT_OK},
+		{name: "errors", report: withFindings(utils.CHECKS, utils.WARNINGS, utils.ERRORS), failOn: utils.FAIL_ON_ERROR, want: utils.EXIT_ERRORS},
+		{name: "warnings below threshold", report: withFindings(utils.WARNINGS), failOn: utils.FAIL_ON_ERROR, want: utils.EXIT_OK},
+		{name: "warnings", report: withFindings(utils.WARNINGS), failOn: utils.FAIL_ON_WARNING, want: utils.EXIT_WARNINGS},
+		{name: "errors and warnings", report: withFindings(utils.WARNINGS, utils.ERRORS), failOn: utils.FAIL_ON_WARNING, want: utils.EXIT_ERRORS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.ExitCode(tt.report, tt.failOn); got != tt.want {
+				t.Errorf("exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.111.0", "0.111.0", 0},
+		{"v0.99.0", "0.100.0", -1},
+		{"1.0", "0.200.3", 1},
+		{"2.1.0-alpha", "2.1", 0},
+		{"17.0.2+8", "11", 1},
+		{"latest", "0.100.0", 0},
+	}
+	for _, tt := range tests {
+		if got := utils.CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestHostRoot(t *testing.T) {
+	if got := (utils.Commands{}).HostRoot(); got != "/" {
+		t.Errorf("HostRoot() = %q, want /", got)
+	}
+	if got := (utils.Commands{HostRootPath: "/host"}).HostRoot(); got != "/host" {
+		t.Errorf("HostRoot() = %q, want /host", got)
+	}
+}
@@ -0,0 +1,13 @@
+// Package utilstest holds helpers shared by the tests of the checks.
+package utilstest
+
+import "otel-checker/checks/utils"
+
+// Subjects returns the subjects of the findings of report with the given severity, in the order they were added.
+func Subjects(report *utils.Report, severity utils.Severity) []string {
+	var subjects []string
+	for _, f := range report.BySeverity(severity) {
+		subjects = append(subjects, f.Subject)
+	}
+	return subjects
+}
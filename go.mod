@@ -3,9 +3,12 @@ module otel-checker
 go 1.22.0
 
 require (
-	github.com/fatih/color v1.17.0 // indirect
+	github.com/fatih/color v1.17.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.18.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -3,11 +3,16 @@ package main
 import (
 	"embed"
 	_ "embed"
-	"html/template"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
+	"os"
 
 	checks "otel-checker/checks"
+	"otel-checker/checks/utils"
+
+	"github.com/fatih/color"
 )
 
 //go:embed static/*
@@ -16,32 +21,29 @@ var static embed.FS
 //go:embed tmpl/*
 var tmpls embed.FS
 
-var messages map[string][]string
-
 func main() {
-	messages = checks.RunAllChecks()
-	mux := http.NewServeMux()
+	commands, err := utils.ParseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		utils.PrintUsage(os.Stdout)
+		os.Exit(utils.EXIT_OK)
+	}
+	if err != nil {
+		fmt.Println(color.RedString(err.Error()))
+		fmt.Println(`Run "otel-checker -h" to see all the flags`)
+		os.Exit(utils.EXIT_FAILURE)
+	}
 
-	t, err := template.ParseFS(tmpls, "tmpl/*.tmpl")
+	report, err := checks.RunAllChecks(commands)
 	if err != nil {
-		panic(err)
+		log.Println("could not run checks:", err)
+		os.Exit(utils.EXIT_FAILURE)
+	}
+	if !commands.Serve {
+		os.Exit(utils.ExitCode(report, commands.FailOn))
 	}
 
-	mux.Handle("/static/", http.FileServer(http.FS(static)))
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		err = t.ExecuteTemplate(w, "index.html.tmpl", struct {
-			Messages map[string][]string
-		}{
-			Messages: messages,
-		})
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	})
-
-	log.Println("Application available on http://localhost:8080")
-	if err := http.ListenAndServe(":8080", mux); err != nil {
+	if err := serve(commands, report); err != nil {
 		log.Println("server failed:", err)
+		os.Exit(utils.EXIT_FAILURE)
 	}
 }
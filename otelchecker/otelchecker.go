@@ -0,0 +1,124 @@
+// Package otelchecker runs the otel-checker checks from other Go programs.
+//
+// Unlike the otel-checker command, it doesn't read flags, print results or
+// exit: the checks run against the options, environment variables and
+// filesystem passed to Run, and the findings are returned in a Report.
+package otelchecker
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+
+	"otel-checker/checks"
+	"otel-checker/checks/utils"
+)
+
+type Report = utils.Report
+type Finding = utils.Finding
+type Location = utils.Location
+type Severity = utils.Severity
+
+const SeverityError = utils.ERRORS
+const SeverityWarning = utils.WARNINGS
+const SeveritySuccess = utils.CHECKS
+
+// Options selects the checks to run and the environment they run against.
+type Options struct {
+	// Language used for instrumentation, one of Languages().
+	Language string
+	// Components to check, from Components(). The Grafana Cloud checks always run.
+	Components          []string
+	AutoInstrumentation bool
+	InstrumentationFile string
+	// PackageJsonPath and CollectorConfigPath are the directories containing
+	// package.json and the collector's config.yaml.
+	PackageJsonPath     string
+	CollectorConfigPath string
+	// BuildFilePath is the pom.xml, build.gradle or build.gradle.kts of a Java
+	// application. When empty, the one in the current directory is used.
+	BuildFilePath string
+	// CollectorConfigs are the files or URIs of the collector config, merged in
+	// order. When set, they are used instead of CollectorConfigPath.
+	CollectorConfigs []string
+	// CollectorBinary and CollectorBuilderConfig are the collector binary, or
+	// the ocb builder config it was built with, to check the config against.
+	CollectorBinary        string
+	CollectorBuilderConfig string
+	// AlloyConfigPath is the Alloy config file, or a directory of .alloy files.
+	AlloyConfigPath string
+	// AlloyConvert reports whether each component of the collector config can
+	// be converted to Alloy. It needs the alloy and collector components.
+	AlloyConvert bool
+	// BeylaConfigPath is the Beyla config file. When empty, the one in the
+	// BEYLA_CONFIG_PATH environment variable is used, if any.
+	BeylaConfigPath string
+	// HostRootPath is the directory the /proc and /sys of the host are read
+	// from, to check the Beyla prerequisites. When empty, / is used.
+	HostRootPath string
+
+	// Env holds the environment variables seen by the checks. When nil, the
+	// environment of the current process is used.
+	Env map[string]string
+	// FS is the filesystem the paths above are read from. When nil, files are
+	// read from the host. Paths are resolved against the root of FS.
+	FS fs.FS
+	// RunCommand runs programs such as "node -v", and returns their standard
+	// output. For the programs printing to their standard error, such as
+	// "java -version", it must return the standard error too. When nil,
+	// programs are run on the host, and killed when ctx is done.
+	RunCommand func(name string, args ...string) ([]byte, error)
+	// HTTPClient is used by the checks making requests. When nil, a client with a timeout of 30 seconds is used.
+	HTTPClient *http.Client
+}
+
+// Run runs the checks selected by opts. It returns an error if opts are not
+// valid or ctx is done before all checks ran, in which case the report
+// contains the findings of the checks that already ran.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	commands := utils.Commands{
+		Language:               opts.Language,
+		Components:             opts.Components,
+		AutoInstrumentation:    opts.AutoInstrumentation,
+		InstrumentationFile:    opts.InstrumentationFile,
+		PackageJsonPath:        utils.DirPath(opts.PackageJsonPath),
+		BuildFilePath:          opts.BuildFilePath,
+		CollectorConfigPath:    utils.DirPath(opts.CollectorConfigPath),
+		CollectorConfigs:       opts.CollectorConfigs,
+		CollectorBinary:        opts.CollectorBinary,
+		CollectorBuilderConfig: opts.CollectorBuilderConfig,
+		AlloyConfigPath:        opts.AlloyConfigPath,
+		AlloyConvert:           opts.AlloyConvert,
+		BeylaConfigPath:        opts.BeylaConfigPath,
+		HostRootPath:           opts.HostRootPath,
+		Output:                 utils.OUTPUT_TEXT,
+		FailOn:                 utils.FAIL_ON_ERROR,
+	}
+	if err := commands.Validate(); err != nil {
+		return utils.NewReport(), err
+	}
+
+	env := utils.DefaultEnv()
+	env.Context = ctx
+	env.FS = opts.FS
+	if opts.Env != nil {
+		env.Getenv = utils.MapGetenv(opts.Env)
+	}
+	if opts.RunCommand != nil {
+		env.RunCommand = opts.RunCommand
+	}
+	if opts.HTTPClient != nil {
+		env.HTTPClient = opts.HTTPClient
+	}
+	return checks.Run(env, commands)
+}
+
+// Languages returns the values accepted by Options.Language.
+func Languages() []string {
+	return utils.Languages()
+}
+
+// Components returns the values accepted by Options.Components.
+func Components() []string {
+	return utils.Components()
+}
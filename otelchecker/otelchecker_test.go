@@ -0,0 +1,148 @@
+package otelchecker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"otel-checker/otelchecker"
+)
+
+// roundTripper stubs the responses of an http.Client.
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// findingOf returns the first finding about subject, or about a message
+// starting with subject for the findings without one.
+func findingOf(report *otelchecker.Report, subject string) (otelchecker.Finding, bool) {
+	for _, f := range report.Findings {
+		if f.Subject == subject || (f.Subject == "" && strings.HasPrefix(f.Message, subject)) {
+			return f, true
+		}
+	}
+	return otelchecker.Finding{}, false
+}
+
+func TestRun(t *testing.T) {
+	var authorization string
+	var commands []string
+	report, err := otelchecker.Run(context.Background(), otelchecker.Options{
+		Language:            "js",
+		Components:          []string{"sdk"},
+		AutoInstrumentation: true,
+		PackageJsonPath:     "app",
+		FS: fstest.MapFS{
+			"app/package.json": {Data: []byte(`{"dependencies": {"@opentelemetry/api": "^1.9.0"}}`)},
+		},
+		Env: map[string]string{
+			"OTEL_SERVICE_NAME":           "my-service",
+			"OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp-gateway-prod-us-east-0.grafana.net/otlp",
+			"OTEL_EXPORTER_OTLP_HEADERS":  "Authorization=Basic abc",
+		},
+		RunCommand: func(name string, args ...string) ([]byte, error) {
+			commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+			return []byte("v14.21.3\n"), nil
+		},
+		HTTPClient: &http.Client{Transport: roundTripper(func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return &http.Response{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized", Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		})},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		subject  string
+		severity otelchecker.Severity
+	}{
+		{subject: "OTEL_SERVICE_NAME", severity: otelchecker.SeveritySuccess},
+		{subject: "Error while testing credentials", severity: otelchecker.SeverityError},
+		{subject: "node", severity: otelchecker.SeverityError},
+		{subject: "@opentelemetry/api", severity: otelchecker.SeveritySuccess},
+		{subject: "@opentelemetry/auto-instrumentations-node", severity: otelchecker.SeverityError},
+	}
+	for _, tt := range tests {
+		if f, ok := findingOf(report, tt.subject); !ok || f.Severity != tt.severity {
+			t.Errorf("finding about %s = %+v, want severity %s", tt.subject, f, tt.severity)
+		}
+	}
+	if f, _ := findingOf(report, "@opentelemetry/api"); f.Location == nil || f.Location.File != "app/package.json" {
+		t.Errorf("location = %+v, want app/package.json", f.Location)
+	}
+	if authorization != "Basic abc" {
+		t.Errorf("Authorization header = %q, want Basic abc", authorization)
+	}
+	if len(commands) != 1 || commands[0] != "node -v" {
+		t.Errorf("commands = %v, want [node -v]", commands)
+	}
+}
+
+func TestRunDefaults(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies": {"@opentelemetry/api": "^1.9.0"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OTEL_SERVICE_NAME", "my-service")
+	// Credentials are not checked against localhost, so no request is made.
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+
+	report, err := otelchecker.Run(context.Background(), otelchecker.Options{
+		Language:            "js",
+		Components:          []string{"sdk"},
+		AutoInstrumentation: true,
+		PackageJsonPath:     dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f, ok := findingOf(report, "OTEL_SERVICE_NAME"); !ok || f.Severity != otelchecker.SeveritySuccess {
+		t.Errorf("finding about OTEL_SERVICE_NAME = %+v, want the variable of the process to be read", f)
+	}
+	f, ok := findingOf(report, "@opentelemetry/api")
+	if !ok || f.Severity != otelchecker.SeveritySuccess || f.Location.File != filepath.Join(dir, "package.json") {
+		t.Errorf("finding about @opentelemetry/api = %+v, want package.json to be read from the host", f)
+	}
+}
+
+func TestRunInvalidOptions(t *testing.T) {
+	report, err := otelchecker.Run(context.Background(), otelchecker.Options{Language: "cobol", Components: []string{"sdk"}, AutoInstrumentation: true})
+	if err == nil {
+		t.Error("expected an error for an unknown language")
+	}
+	if report == nil || len(report.Findings) != 0 {
+		t.Errorf("report = %+v, want an empty report", report)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := otelchecker.Run(ctx, otelchecker.Options{
+		Language:            "js",
+		Components:          []string{"sdk"},
+		AutoInstrumentation: true,
+		FS:                  fstest.MapFS{},
+		Env:                 map[string]string{},
+		RunCommand: func(name string, args ...string) ([]byte, error) {
+			t.Errorf("ran %s after the context was cancelled", name)
+			return nil, nil
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if len(report.Findings) != 0 {
+		t.Errorf("findings = %+v, want none", report.Findings)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	checks "otel-checker/checks"
+	"otel-checker/checks/utils"
+)
+
+// How often the input files are checked for changes with -watch.
+var watchInterval = time.Second
+
+// server serves the results of the checks and re-runs them on demand.
+type server struct {
+	commands utils.Commands
+	template *template.Template
+	// hosts are the values of the Host header the server accepts, so that
+	// pages of other sites can't reach it through DNS rebinding. When empty,
+	// because the server listens on all interfaces, any host is accepted.
+	hosts []string
+
+	mu          sync.Mutex
+	report      *utils.Report
+	subscribers map[chan []byte]struct{}
+}
+
+func newServer(commands utils.Commands, report *utils.Report) (*server, error) {
+	t, err := template.New("").Funcs(template.FuncMap{
+		"contains": slices.Contains[[]string],
+	}).ParseFS(tmpls, "tmpl/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	return &server{
+		commands:    commands,
+		template:    t,
+		report:      report,
+		subscribers: make(map[chan []byte]struct{}),
+	}, nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/static/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("GET /api/results", s.handleResults)
+	mux.HandleFunc("POST /api/run", s.handleRun)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("/", s.handleIndex)
+	return s.checkOrigin(mux)
+}
+
+// allowHosts sets the hosts accepted by the server listening on addr, which
+// includes localhost when addr is a loopback address.
+func (s *server) allowHosts(addr net.Addr) {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || tcpAddr.IP.IsUnspecified() {
+		return
+	}
+	s.hosts = []string{tcpAddr.String()}
+	if tcpAddr.IP.IsLoopback() {
+		port := fmt.Sprint(tcpAddr.Port)
+		for _, host := range []string{"localhost", "127.0.0.1", "::1"} {
+			if h := net.JoinHostPort(host, port); !slices.Contains(s.hosts, h) {
+				s.hosts = append(s.hosts, h)
+			}
+		}
+	}
+}
+
+// checkOrigin rejects the requests for another host than the server, and
+// the requests changing state that come from the pages of other sites.
+// Requests without Origin, such as the ones of curl, are accepted.
+func (s *server) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.hosts) > 0 && !slices.Contains(s.hosts, r.Host) {
+			http.Error(w, fmt.Sprintf("unexpected host %q", r.Host), http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+				http.Error(w, "cross-site requests are not allowed", http.StatusForbidden)
+				return
+			}
+			if origin := r.Header.Get("Origin"); origin != "" {
+				if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+					http.Error(w, fmt.Sprintf("requests from %s are not allowed", origin), http.StatusForbidden)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) current() (utils.Commands, *utils.Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands, s.report
+}
+
+// rerun runs the checks again with commands and notifies the pages listening for events.
+func (s *server) rerun(commands utils.Commands) *utils.Report {
+	report, err := checks.Run(utils.DefaultEnv(), commands)
+	if err != nil {
+		log.Println("could not run checks:", err)
+	}
+
+	var indented, data bytes.Buffer
+	if err := utils.PrintJSON(&indented, report, commands); err != nil {
+		log.Println("could not encode results:", err)
+	}
+	// Each server-sent event has to fit on a single line
+	if err := json.Compact(&data, indented.Bytes()); err != nil {
+		log.Println("could not encode results:", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = commands
+	s.report = report
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- data.Bytes():
+		default:
+			// The page is not keeping up, it will get the next update instead
+		}
+	}
+	return report
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	commands, report := s.current()
+	err := s.template.ExecuteTemplate(w, "index.html.tmpl", struct {
+		Commands   utils.Commands
+		Report     *utils.Report
+		Severities []utils.Severity
+		Languages  []string
+		Components []string
+	}{
+		Commands:   commands,
+		Report:     report,
+		Severities: utils.Severities,
+		Languages:  utils.Languages(),
+		Components: utils.Components(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *server) handleResults(w http.ResponseWriter, r *http.Request) {
+	commands, report := s.current()
+	w.Header().Set("Content-Type", "application/json")
+	if err := utils.PrintJSON(w, report, commands); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleRun runs the checks again. When the request has form values, they
+// replace the language, components and paths the checks run with.
+func (s *server) handleRun(w http.ResponseWriter, r *http.Request) {
+	commands, _ := s.current()
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Form.Has("language") {
+		var err error
+		if commands, err = commandsFromForm(commands, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := commands.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report := s.rerun(commands)
+	w.Header().Set("Content-Type", "application/json")
+	if err := utils.PrintJSON(w, report, commands); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// commandsFromForm replaces the values of commands that can be changed on the web page with the ones in the form.
+// The collector config sources of the form can only be files, unless they were already set on the command line,
+// so that the page can't make otel-checker fetch URLs or read environment variables.
+func commandsFromForm(commands utils.Commands, r *http.Request) (utils.Commands, error) {
+	previousConfigs := commands.CollectorConfigs
+	commands.Language = r.Form.Get("language")
+	commands.Components = nil
+	for _, c := range r.Form["components"] {
+		commands.Components = append(commands.Components, utils.ParseComponents(c)...)
+	}
+	commands.AutoInstrumentation = r.Form.Get("auto-instrumentation") != ""
+	commands.InstrumentationFile = r.Form.Get("instrumentation-file")
+	commands.PackageJsonPath = utils.DirPath(r.Form.Get("package-json-path"))
+	commands.BuildFilePath = r.Form.Get("build-file-path")
+	commands.CollectorConfigPath = utils.DirPath(r.Form.Get("collector-config-path"))
+	commands.CollectorConfigs = nil
+	for _, source := range strings.Split(r.Form.Get("collector-config"), "\n") {
+		if source = strings.TrimSpace(source); source == "" {
+			continue
+		}
+		if _, ok := utils.ConfigFile(source); !ok && !slices.Contains(previousConfigs, source) {
+			return commands, fmt.Errorf("collector config source %q is not a file. Only files can be set on the web page, the other sources have to be passed with \"-collector-config\"", source)
+		}
+		commands.CollectorConfigs = append(commands.CollectorConfigs, source)
+	}
+	commands.AlloyConfigPath = r.Form.Get("alloy-config-path")
+	commands.BeylaConfigPath = r.Form.Get("beyla-config-path")
+	return commands, nil
+}
+
+// handleEvents streams the results of every re-run as server-sent events.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	updates := make(chan []byte, 1)
+	s.mu.Lock()
+	s.subscribers[updates] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, updates)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-updates:
+			fmt.Fprintf(w, "event: results\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// watch re-runs the checks whenever one of the input files changes, until ctx is done.
+func (s *server) watch(ctx context.Context) {
+	commands, _ := s.current()
+	log.Printf("Watching %v for changes", utils.InputFiles(commands))
+
+	previous := fileStates(utils.InputFiles(commands))
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// The files depend on the values submitted on the web page
+			commands, _ := s.current()
+			current := fileStates(utils.InputFiles(commands))
+			if current != previous {
+				previous = current
+				log.Println("Files changed, running checks again")
+				s.rerun(commands)
+			}
+		}
+	}
+}
+
+// fileStates returns a value that changes whenever one of the files is created, removed or modified.
+func fileStates(files []string) string {
+	var states bytes.Buffer
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			fmt.Fprintf(&states, "%s:missing;", f)
+			continue
+		}
+		fmt.Fprintf(&states, "%s:%d:%d;", f, info.ModTime().UnixNano(), info.Size())
+	}
+	return states.String()
+}
+
+// serve serves the report on a web page until SIGINT or SIGTERM is received.
+func serve(commands utils.Commands, report *utils.Report) error {
+	s, err := newServer(commands, report)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", commands.Listen)
+	if err != nil {
+		return err
+	}
+	s.allowHosts(listener.Addr())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	httpServer := &http.Server{
+		Handler: s.handler(),
+		// Cancels the event streams, which would otherwise hold the shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Println("server shutdown failed:", err)
+		}
+	}()
+	if commands.Watch {
+		go s.watch(ctx)
+	}
+
+	log.Printf("Application available on http://%s", listener.Addr())
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
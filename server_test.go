@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"otel-checker/checks/utils"
+)
+
+const testCollectorConfig = `
+receivers:
+  otlp:
+    protocols:
+      http:
+exporters:
+  debug:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [debug]
+`
+
+// newTestServer serves the results of the collector checks of a config in a
+// temporary directory, returning the server and the path of the config.
+func newTestServer(t *testing.T) (*server, *httptest.Server, string) {
+	// The credentials are not checked without an endpoint, so no request is made.
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte(testCollectorConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	commands := utils.Commands{
+		Language:            "go",
+		Components:          []string{"collector"},
+		AutoInstrumentation: true,
+		CollectorConfigs:    []string{config},
+		Output:              utils.OUTPUT_TEXT,
+		FailOn:              utils.FAIL_ON_ERROR,
+	}
+	s, err := newServer(commands, utils.NewReport())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	s.allowHosts(ts.Listener.Addr())
+	return s, ts, config
+}
+
+func TestAllowHosts(t *testing.T) {
+	s := &server{}
+	s.allowHosts(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080})
+	if want := []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080"}; !slices.Equal(s.hosts, want) {
+		t.Errorf("hosts = %v, want %v", s.hosts, want)
+	}
+
+	s = &server{}
+	s.allowHosts(&net.TCPAddr{IP: net.IPv4zero, Port: 8080})
+	if len(s.hosts) != 0 {
+		t.Errorf("hosts = %v, want any host when listening on all interfaces", s.hosts)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		host    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "same origin", method: http.MethodPost, headers: map[string]string{"Origin": ts.URL, "Sec-Fetch-Site": "same-origin"}, want: http.StatusOK},
+		{name: "without origin", method: http.MethodPost, want: http.StatusOK},
+		{name: "foreign host", method: http.MethodGet, host: "attacker.example:80", want: http.StatusForbidden},
+		{name: "cross-site", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "cross-site"}, want: http.StatusForbidden},
+		{name: "same site", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "same-site"}, want: http.StatusForbidden},
+		{name: "mismatched origin", method: http.MethodPost, headers: map[string]string{"Origin": "http://attacker.example"}, want: http.StatusForbidden},
+		{name: "cross-site read", method: http.MethodGet, headers: map[string]string{"Origin": "http://attacker.example", "Sec-Fetch-Site": "cross-site"}, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/api/results"
+			if tt.method == http.MethodPost {
+				path = "/api/run"
+			}
+			req, err := http.NewRequest(tt.method, ts.URL+path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandsFromForm(t *testing.T) {
+	previous := utils.Commands{CollectorConfigs: []string{"env:EXTRA_CONFIG"}}
+	tests := []struct {
+		name    string
+		sources string
+		want    []string
+		wantErr bool
+	}{
+		{name: "files", sources: "config.yaml\nfile:/etc/otelcol/extra.yaml\n", want: []string{"config.yaml", "file:/etc/otelcol/extra.yaml"}},
+		{name: "source passed on the command line", sources: "config.yaml\nenv:EXTRA_CONFIG", want: []string{"config.yaml", "env:EXTRA_CONFIG"}},
+		{name: "http", sources: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{name: "https", sources: "config.yaml\nhttps://attacker.example/config.yaml", wantErr: true},
+		{name: "environment variable", sources: "env:GRAFANA_CLOUD_API_KEY", wantErr: true},
+		{name: "inline yaml", sources: "yaml:exporters::debug: {}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"language": {"go"}, "components": {"collector"}, "collector-config": {tt.sources}}
+			r := httptest.NewRequest(http.MethodPost, "/api/run", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			commands, err := commandsFromForm(previous, r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want an error: %t", err, tt.wantErr)
+			}
+			if err == nil && !slices.Equal(commands.CollectorConfigs, tt.want) {
+				t.Errorf("collector configs = %v, want %v", commands.CollectorConfigs, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRejectsSourcesThatAreNotFiles(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+	resp, err := ts.Client().PostForm(ts.URL+"/api/run", url.Values{
+		"language":             {"go"},
+		"components":           {"collector"},
+		"auto-instrumentation": {"on"},
+		"collector-config":     {"http://169.254.169.254/latest/meta-data"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+// results is the part of the JSON results the tests look at.
+type results struct {
+	Version    int             `json:"version"`
+	Language   string          `json:"language"`
+	Components []string        `json:"components"`
+	Findings   []utils.Finding `json:"findings"`
+}
+
+func getResults(t *testing.T, ts *httptest.Server) results {
+	resp, err := ts.Client().Get(ts.URL + "/api/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type = %q, want application/json", ct)
+	}
+	var r results
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// subscribe connects to the event stream of the server, returning the data of the results events.
+func subscribe(t *testing.T, ts *httptest.Server) <-chan results {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Returns once the headers are flushed, after the subscription is registered.
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q, want text/event-stream", ct)
+	}
+
+	events := make(chan results, 10)
+	go func() {
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, 1<<20)
+		event := ""
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: ") && event == "results":
+				var r results
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &r); err == nil {
+					events <- r
+				}
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan results) results {
+	select {
+	case r := <-events:
+		return r
+	case <-time.After(10 * time.Second):
+		t.Fatal("no results event received")
+		return results{}
+	}
+}
+
+func TestHandleResults(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+	r := getResults(t, ts)
+	if r.Version != utils.SchemaVersion || r.Language != "go" || !slices.Equal(r.Components, []string{"collector"}) || len(r.Findings) != 0 {
+		t.Errorf("results = %+v, want the initial empty report", r)
+	}
+}
+
+func TestHandleRun(t *testing.T) {
+	s, ts, config := newTestServer(t)
+	events := subscribe(t, ts)
+
+	resp, err := ts.Client().Post(ts.URL+"/api/run", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var run results
+	err = json.NewDecoder(resp.Body).Decode(&run)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Findings) == 0 {
+		t.Fatal("no findings after running the checks")
+	}
+	if _, report := s.current(); len(report.Findings) != len(run.Findings) {
+		t.Errorf("report has %d findings, want the %d of the run", len(report.Findings), len(run.Findings))
+	}
+	if got := getResults(t, ts); len(got.Findings) != len(run.Findings) {
+		t.Errorf("results have %d findings, want the %d of the run", len(got.Findings), len(run.Findings))
+	}
+	if got := nextEvent(t, events); len(got.Findings) != len(run.Findings) {
+		t.Errorf("event has %d findings, want the %d of the run", len(got.Findings), len(run.Findings))
+	}
+
+	// The values of the form replace the ones the checks run with.
+	resp, err = ts.Client().PostForm(ts.URL+"/api/run", url.Values{
+		"language":             {"java"},
+		"components":           {"collector"},
+		"auto-instrumentation": {"on"},
+		"collector-config":     {config},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := getResults(t, ts); got.Language != "java" {
+		t.Errorf("language = %q, want java", got.Language)
+	}
+	if got := nextEvent(t, events); got.Language != "java" {
+		t.Errorf("language of the event = %q, want java", got.Language)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	defer func(interval time.Duration) { watchInterval = interval }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	s, ts, config := newTestServer(t)
+	events := subscribe(t, ts)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.watch(ctx)
+
+	// The config is changed until the watcher, which may not have seen its first
+	// version yet, runs the checks again.
+	for i := 0; ; i++ {
+		if err := os.WriteFile(config, []byte(fmt.Sprintf("%s# change %d\n", testCollectorConfig, i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case r := <-events:
+			if len(r.Findings) == 0 {
+				t.Error("no findings after the config changed")
+			}
+			if _, report := s.current(); len(report.Findings) != len(r.Findings) {
+				t.Errorf("report has %d findings, want the %d of the event", len(report.Findings), len(r.Findings))
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+			if i == 200 {
+				t.Fatal("no results event received after the config changed")
+			}
+		}
+	}
+}